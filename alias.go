@@ -0,0 +1,98 @@
+package hugoext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+// defaultAliasTemplates are the redirect stubs used without an alias layout by output extension,
+// other extensions get a markdown link.
+var defaultAliasTemplates = map[string]*template.Template{
+	"gmi": template.Must(template.New("alias").Parse(
+		"# {{ .Title }}\n\nThis page has moved.\n\n=> {{ .Link }} {{ .Title }}\n")),
+	"md": template.Must(template.New("alias").Parse(
+		"# {{ .Title }}\n\nThis page has moved to [{{ .Title }}]({{ .Link }}).\n")),
+}
+
+// aliasData is what alias templates are executed with.
+type aliasData struct {
+	hugo.PageMetadata
+	// Link is the path of the page the alias redirects to.
+	Link string
+}
+
+// aliasTemplate returns layouts/alias.<ext> or layouts/_default/alias.<ext> like hugo's alias.html,
+// or the built in redirect stub.
+func (l layouts) aliasTemplate() (*template.Template, error) {
+	for _, file := range []string{
+		filepath.Join(l.dir, "alias."+l.ext),
+		filepath.Join(l.dir, "_default", "alias."+l.ext),
+	} {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+
+		tmpl, err := template.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("parse template: %w", err)
+		}
+
+		return tmpl, nil
+	}
+
+	if tmpl, ok := defaultAliasTemplates[l.ext]; ok {
+		return tmpl, nil
+	}
+
+	return defaultAliasTemplates["md"], nil
+}
+
+// trimURLExt removes a file extension like .html from a url or alias, the output extension is set
+// by targetPath.
+func trimURLExt(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+
+	return strings.TrimSuffix(u, path.Ext(u))
+}
+
+// aliasDestination is the destination of a redirect stub. Like hugo, relative aliases are on the same
+// level as the page, absolute ones are relative to the root of the page language in langDir.
+func aliasDestination(file *File, alias, langDir string) string {
+	if !strings.HasPrefix(alias, "/") {
+		dir := path.Dir(strings.TrimSuffix(path.Join("/", file.Destination), "/"))
+		return trimURLExt(path.Join(dir, alias))
+	}
+
+	if langDir != "" && !strings.HasPrefix(alias, "/"+langDir+"/") {
+		alias = path.Join("/", langDir, alias)
+	}
+
+	return trimURLExt(path.Join("/", alias))
+}
+
+// writeAlias writes the redirect stub for the page at the alias path.
+func writeAlias(tmpl *template.Template, file *File, alias, langDir, dest, ext string,
+	uglyURLs bool, log logger) (string, error) {
+	var buf bytes.Buffer
+
+	err := tmpl.Execute(&buf, aliasData{
+		PageMetadata: file.Metadata,
+		Link:         pageLink(file.Destination, ext, uglyURLs, file.home()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("execute template: %w", err)
+	}
+
+	stub := File{Destination: aliasDestination(file, alias, langDir), NewBody: buf.Bytes()}
+
+	return stub.write(dest, ext, uglyURLs, log)
+}
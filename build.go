@@ -0,0 +1,815 @@
+package hugoext
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+const (
+	defaultExt           = "md"
+	defaultProcessor     = ""
+	defaultSource        = "content"
+	defaultStatic        = "static"
+	defaultDestination   = "public"
+	defaultConfigPath    = "config.toml"
+	defaultSectionOnRoot = "posts"
+	defaultSectionSort   = "date"
+	defaultOnCollision   = "fail"
+
+	defaultPermalinkFormat = "/:year/:month/:title/"
+)
+
+// Options configure a build like the command line flags of hugoext. Empty paths, the extension,
+// sort and collision policy fall back to the defaults of DefaultOptions.
+type Options struct {
+	// Ext is the output extension, also used to find the layouts.
+	Ext string
+	// Pipe is the program the markdown of each page is piped through, the content is passed through
+	// without one.
+	Pipe string
+	// Processor converts the pages in process instead of the Pipe program.
+	Processor   Processor
+	Source      string
+	Destination string
+	// Config is the path of the hugo config file.
+	Config string
+
+	// Static mirrors StaticDir and its StaticDir-<ext> override into the destination.
+	Static    bool
+	StaticDir string
+
+	// BuildDrafts, BuildFuture and BuildExpired publish content the hugo config doesn't.
+	BuildDrafts  bool
+	BuildFuture  bool
+	BuildExpired bool
+
+	// NoSectionList disables appending section lists, SectionOnRoot is also listed on the root.
+	NoSectionList bool
+	SectionOnRoot string
+	// SectionSort sorts section lists by date, lastmod, weight or title.
+	SectionSort string
+
+	// Sitemap writes sitemap-<ext>.xml and sitemap-<ext>.txt of all written files.
+	Sitemap bool
+	// BaseURL of the output, e.g. gemini://example.org/, for the sitemap and ref links. The sitemap
+	// falls back to baseURL from the hugo config, ref links start at the output root without one.
+	BaseURL string
+
+	// Manifest of written files, defaults to .hugoext-<ext>.json in the destination.
+	Manifest string
+	// Clean removes files written by a previous build that are no longer generated.
+	Clean bool
+
+	// OnCollision handles pages with the same destination: fail, suffix or skip.
+	OnCollision string
+
+	// DryRun plans the build without creating any files, DryRunPipe implies it and still runs the
+	// pipe to report the output size or the error of each page in the plan.
+	DryRun     bool
+	DryRunPipe bool
+
+	// Log receives the progress of the build, nothing is written without one.
+	Log io.Writer
+}
+
+// DefaultOptions returns the options hugoext uses without command line flags.
+func DefaultOptions() Options {
+	return Options{
+		Ext:           defaultExt,
+		Pipe:          defaultProcessor,
+		Source:        defaultSource,
+		Destination:   defaultDestination,
+		Config:        defaultConfigPath,
+		StaticDir:     defaultStatic,
+		SectionOnRoot: defaultSectionOnRoot,
+		SectionSort:   defaultSectionSort,
+		OnCollision:   defaultOnCollision,
+	}
+}
+
+// withDefaults fills in the defaults of empty options.
+func (opts Options) withDefaults() Options {
+	defaults := DefaultOptions()
+
+	for _, field := range []struct {
+		value *string
+		def   string
+	}{
+		{&opts.Ext, defaults.Ext},
+		{&opts.Source, defaults.Source},
+		{&opts.Destination, defaults.Destination},
+		{&opts.Config, defaults.Config},
+		{&opts.StaticDir, defaults.StaticDir},
+		{&opts.SectionSort, defaults.SectionSort},
+		{&opts.OnCollision, defaults.OnCollision},
+	} {
+		if *field.value == "" {
+			*field.value = field.def
+		}
+	}
+
+	if opts.Manifest == "" {
+		opts.Manifest = filepath.Join(opts.Destination, ".hugoext-"+opts.Ext+".json")
+	}
+
+	if opts.DryRunPipe {
+		opts.DryRun = true
+	}
+
+	return opts
+}
+
+// Result is what a build published, or would publish in a dry run.
+type Result struct {
+	Site Site
+	// Tree holds the published pages and resources and the skipped files.
+	Tree FileTree
+	// Sections are the section listings by their output directory.
+	Sections map[string]*Section
+	Static   []StaticFile
+
+	// Plan is the report of a dry run, nil otherwise.
+	Plan *Plan
+	// Manifest lists the written files, nil for a dry run.
+	Manifest *Manifest
+	// Removed are the stale files of previous builds removed with Clean.
+	Removed []ManifestEntry
+}
+
+// Build converts the hugo content to the output format and writes it to the destination.
+func Build(ctx context.Context, opts Options) (*Result, error) {
+	b, err := newBuilder(opts.withDefaults())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Site: b.site}
+
+	if result.Tree, err = b.collectPages(); err != nil {
+		return nil, err
+	}
+
+	if result.Static, result.Sections, err = b.collectOutputs(&result.Tree); err != nil {
+		return nil, err
+	}
+
+	// no output may overwrite another, checked before anything is processed or written
+	err = resolveOutputCollisions(result, b.langs, b.opts.Destination, b.opts.Ext, b.site.UglyURLs,
+		b.opts.OnCollision, b.log)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeErrs, err := b.process(ctx, &result.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.opts.DryRun {
+		result.Plan = newPlan(&result.Tree, result.Static, result.Sections, b.langs, b.opts.Destination,
+			b.opts.Ext, b.opts.SectionOnRoot, b.site.UglyURLs, pipeErrs)
+
+		return result, nil
+	}
+
+	out, err := b.writeOutputs(result)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Manifest, result.Removed, err = b.writeManifest(out); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// builder holds the options and the settings from the hugo config shared by the steps of a build.
+type builder struct {
+	opts      Options
+	log       logger
+	processor Processor
+
+	site     Site
+	langs    languages
+	layout   layouts
+	publish  publishConfig
+	metaCfg  metadataConfig
+	pathOpts hugo.PathOptions
+}
+
+// newBuilder checks the options and loads the hugo config and the settings derived from it.
+func newBuilder(opts Options) (*builder, error) {
+	b := &builder{opts: opts, log: logger{w: opts.Log}, processor: opts.Processor}
+
+	if _, ok := sectionSorts[opts.SectionSort]; !ok {
+		return nil, fmt.Errorf("unknown section sort %q", opts.SectionSort)
+	}
+
+	if !collisionPolicies[opts.OnCollision] {
+		return nil, fmt.Errorf("unknown collision policy %q", opts.OnCollision)
+	}
+
+	if b.processor == nil {
+		b.processor = Pipe{Command: opts.Pipe}
+	}
+
+	// what are we doing
+	b.log.Printf("hugoext: converting hugo markdown to %v with %v\n", opts.Ext, processorName(b.processor))
+
+	cfg := hugo.Config{Path: opts.Config, Log: opts.Log}
+	if err := cfg.Load(); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	uglyURLs := cfg.GetBool("uglyURLs")
+
+	// options can only enable what the config doesn't
+	b.publish = publishConfig{
+		Drafts:  opts.BuildDrafts || cfg.GetBool("buildDrafts"),
+		Future:  opts.BuildFuture || cfg.GetBool("buildFuture"),
+		Expired: opts.BuildExpired || cfg.GetBool("buildExpired"),
+	}
+
+	loc, err := time.LoadLocation(cfg.GetString("timeZone"))
+	if err != nil {
+		return nil, fmt.Errorf("config: invalid timeZone: %w", err)
+	}
+
+	b.metaCfg = metadataConfig{
+		Location:    loc,
+		Frontmatter: newFrontmatterConfig(cfg.GetStringMap("frontmatter")),
+
+		SummaryLength:  cfg.GetInt("summaryLength"),
+		HasCJKLanguage: cfg.GetBool("hasCJKLanguage"),
+	}
+
+	b.pathOpts = hugo.PathOptions{
+		RemovePathAccents:  cfg.GetBool("removePathAccents"),
+		DisablePathToLower: cfg.GetBool("disablePathToLower"),
+	}
+
+	b.layout = layouts{dir: cfg.GetString("layoutDir"), ext: opts.Ext}
+	if b.layout.dir == "" {
+		b.layout.dir = defaultLayoutDir
+	}
+
+	if b.metaCfg.SummaryLength <= 0 {
+		b.metaCfg.SummaryLength = defaultSummaryLength
+	}
+
+	b.langs = newLanguages(cfg.GetLanguages(), cfg.GetString("defaultContentLanguage"),
+		cfg.GetBool("defaultContentLanguageInSubdir"))
+
+	if cfg.GetBool("enableGitInfo") {
+		// language content directories may be outside of the source or in another repository
+		b.metaCfg.GitLastmod = make(map[string]time.Time)
+
+		for _, dir := range sortedKeys(b.langs.contentDirs(opts.Source)) {
+			lastmod, err := gitLastmod(dir)
+			if err != nil {
+				return nil, fmt.Errorf("config: enableGitInfo: %s: %w", dir, err)
+			}
+
+			for file, date := range lastmod {
+				b.metaCfg.GitLastmod[file] = date
+			}
+		}
+	}
+
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = cfg.GetString("baseURL")
+	}
+
+	permalinks := cfg.GetStringMapString("permalinks")
+	if permalinks == nil {
+		b.log.Printf("config: no permalinks set, using default: %s\n", defaultPermalinkFormat)
+	}
+
+	// validate all patterns up front instead of failing on the first page using one
+	var invalid bool
+
+	for _, section := range sortedKeys(permalinks) {
+		if err := hugo.PathPattern(permalinks[section]).Validate(); err != nil {
+			b.log.Printf("config: permalinks section %s: %v\n", section, err)
+			invalid = true
+		}
+	}
+
+	if invalid {
+		return nil, fmt.Errorf("config: invalid permalinks")
+	}
+
+	b.site = Site{
+		Title:    cfg.GetString("title"),
+		BaseURL:  baseURL,
+		UglyURLs: uglyURLs,
+		TimeZone: loc,
+
+		Languages:               b.langs.List,
+		DefaultLanguage:         b.langs.Default,
+		DefaultLanguageInSubdir: b.langs.InSubdir,
+
+		Permalinks: permalinks,
+	}
+
+	return b, nil
+}
+
+// linkpattern returns the permalink pattern of the section.
+func (b *builder) linkpattern(section string) string {
+	if format, ok := b.site.Permalinks[section]; ok {
+		return format
+	}
+
+	return defaultPermalinkFormat
+}
+
+// collectPages reads the content into a tree of published pages at their destination and places the
+// resources next to them.
+func (b *builder) collectPages() (FileTree, error) {
+	var tree FileTree
+
+	// iterate through file tree source
+	fileChan := make(chan File)
+	walkErr := make(chan error, 1)
+
+	go func() {
+		walkErr <- collectContent(b.opts.Source, b.langs, fileChan, b.log)
+	}()
+
+	// section front matter cascades to all pages below, so read the whole tree first
+	var files []File
+	for file := range fileChan {
+		files = append(files, file)
+	}
+
+	if err := <-walkErr; err != nil {
+		return tree, err
+	}
+
+	cascade, err := collectCascades(files)
+	if err != nil {
+		return tree, err
+	}
+
+	// for each file, get destination path, switch file extension, remove underscore for index
+	now := time.Now()
+
+	for _, file := range files {
+		if file.Resource {
+			tree.Resources = append(tree.Resources, file)
+
+			continue
+		}
+
+		// hugo picks the permalink by the top level section, also for nested sections
+		pattern := b.linkpattern(firstSection(file.Parent))
+
+		err := destinationPath(&file, pattern, b.pathOpts, b.metaCfg, cascade.forPage(file))
+		if err != nil {
+			return tree, fmt.Errorf("failed to derive destination for %v: %w", file.Source, err)
+		}
+
+		if file.Metadata.Date.IsZero() && file.Name != "_index" {
+			b.log.Printf("warning: no date in %s\n", file.Source)
+		}
+
+		file.Destination = b.langs.destination(&file)
+
+		if file.Skip = b.publish.skip(&file.Metadata, now); file.Skip != "" {
+			b.log.Printf("skipping %s %s (%dbytes)\n", file.Skip, file.Source, len(file.Body))
+			tree.Skipped = append(tree.Skipped, file)
+
+			continue
+		}
+
+		tree.Files = append(tree.Files, file)
+	}
+
+	if err := resolveCollisions(&tree, b.opts.Ext, b.site.UglyURLs, b.opts.OnCollision, b.log); err != nil {
+		return tree, err
+	}
+
+	linkTranslations(&tree, b.langs, b.opts.Ext, b.site.UglyURLs)
+
+	// place page bundle resources next to their page and all other non-content files as is
+	bundles := make(map[string][]string)
+	for _, file := range tree.Files {
+		if file.Bundle != "" {
+			bundles[file.Bundle] = append(bundles[file.Bundle], file.Destination)
+		}
+	}
+
+	var resources []File
+	for _, file := range tree.Resources {
+		resolved := file.resolve(bundles)
+		if len(resolved) == 0 {
+			b.log.Printf("skipping resource %s of unpublished bundle\n", file.Source)
+			file.Skip = "unpublished bundle"
+			tree.Skipped = append(tree.Skipped, file)
+
+			continue
+		}
+
+		resources = append(resources, resolved...)
+	}
+
+	tree.Resources = resources
+
+	return tree, nil
+}
+
+// collectOutputs returns the static files and the section listings written besides the pages.
+func (b *builder) collectOutputs(tree *FileTree) ([]StaticFile, map[string]*Section, error) {
+	var (
+		static   []StaticFile
+		sections map[string]*Section
+		err      error
+	)
+
+	if b.opts.Static {
+		// format specific files in static-<ext> take precedence
+		static, err = collectStatic(b.opts.StaticDir, b.opts.StaticDir+"-"+b.opts.Ext)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !b.opts.NoSectionList {
+		sections, err = aggregateSections(tree, b.langs, b.layout, b.opts.Destination, b.opts.Ext,
+			b.opts.SectionSort, b.site.UglyURLs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return static, sections, nil
+}
+
+// process renders the pages of the tree with the processor, it returns the failures of the pipe by
+// source in a dry run of the pipe and does nothing in any other dry run.
+func (b *builder) process(ctx context.Context, tree *FileTree) (map[string]error, error) {
+	if b.opts.DryRun && !b.opts.DryRunPipe {
+		return nil, nil
+	}
+
+	// the hugo baseURL is the http site, ref links only get a base configured for the output
+	refs := newPageIndex(tree, b.langs, b.opts.Ext, b.site.UglyURLs, b.opts.BaseURL, b.log)
+	shortcode := newShortcodes(b.layout, refs, b.log)
+
+	// failures of the pipe by source, a dry run reports them in the plan
+	var pipeErrs map[string]error
+	if b.opts.DryRunPipe {
+		pipeErrs = make(map[string]error)
+	}
+
+	// call proc and pipe content through it, catch output of proc
+	for i, file := range tree.Files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// render shortcodes first, the processor only sees their output
+		body, err := shortcode.render(&file)
+		if err != nil {
+			return nil, fmt.Errorf("shortcodes in %v: %w", file.Source, err)
+		}
+
+		// point internal links at the output of the pages they link to
+		body, err = refs.rewriteLinks(&file, body)
+		if err != nil {
+			return nil, fmt.Errorf("links in %v: %w", file.Source, err)
+		}
+
+		out, err := b.processor.Process(ctx, &Page{
+			Source:      file.Source,
+			Destination: file.Destination,
+			Lang:        file.Lang,
+			Metadata:    file.Metadata,
+			Content:     body,
+		})
+		if err != nil && pipeErrs != nil {
+			pipeErrs[file.Source] = err
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("processor %v for %v: %w", processorName(b.processor), file.Source, err)
+		}
+
+		// wrap in the single page layout if there is one
+		file.NewBody = out
+
+		out, err = b.layout.render(&file)
+		if err != nil {
+			return nil, fmt.Errorf("layout for %v: %w", file.Source, err)
+		}
+
+		// write to source
+		tree.Files[i].NewBody = out
+		b.log.Printf("processed %s (%dbytes)\n", file.Source, len(tree.Files[i].Body))
+	}
+
+	return pipeErrs, nil
+}
+
+// writeOutputs writes the pages, aliases, resources, static files, section listings and sitemaps
+// of the result to the destination, it returns what was written.
+func (b *builder) writeOutputs(result *Result) (*output, error) {
+	ext, destination, uglyURLs := b.opts.Ext, b.opts.Destination, b.site.UglyURLs
+	tree := &result.Tree
+
+	if made, err := mkdir(destination); err != nil {
+		return nil, err
+	} else if made {
+		b.log.Printf("mkdir %s\n", destination)
+	}
+
+	out := &output{
+		destination: destination,
+		sitemap:     Sitemap{BaseURL: b.site.BaseURL},
+		manifest:    Manifest{Destination: destination},
+	}
+
+	// write new content to destination
+	for i, file := range tree.Files {
+		newpath, err := file.write(destination, ext, uglyURLs, b.log)
+		if err != nil {
+			return nil, fmt.Errorf("new file write '%v': %w", file.Name, err)
+		}
+
+		b.log.Printf("written %s (%dbytes)\n", newpath, len(file.NewBody))
+
+		err = out.add(newpath, file.Metadata.Lastmod, ManifestEntry{
+			Source:   file.Source,
+			Pipe:     processorName(b.processor),
+			Metadata: &tree.Files[i].Metadata,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// redirect stubs for aliases
+	aliasTmpl, err := b.layout.aliasTemplate()
+	if err != nil {
+		return nil, fmt.Errorf("alias layout: %w", err)
+	}
+
+	for _, file := range tree.Files {
+		for _, alias := range file.Metadata.Aliases {
+			newpath, err := writeAlias(aliasTmpl, &file, alias, b.langs.dir(file.Lang), destination, ext, uglyURLs,
+				b.log)
+			if err != nil {
+				return nil, fmt.Errorf("alias '%v' of %v: %w", alias, file.Source, err)
+			}
+
+			b.log.Printf("written alias %s for %s\n", newpath, file.Source)
+
+			if _, err := out.track(newpath, ManifestEntry{Source: file.Source}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// copy resources and static files verbatim
+	for _, file := range tree.Resources {
+		newpath, err := file.copy(destination, b.log)
+		if err != nil {
+			return nil, fmt.Errorf("resource copy '%v': %w", file.Source, err)
+		}
+
+		b.log.Printf("copied %s to %s\n", file.Source, newpath)
+
+		if err := out.add(newpath, time.Time{}, ManifestEntry{Source: file.Source}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range result.Static {
+		newpath, copied, err := file.sync(destination, b.log)
+		if err != nil {
+			return nil, fmt.Errorf("static copy '%v': %w", file.Source, err)
+		}
+
+		if copied {
+			b.log.Printf("copied %s to %s\n", file.Source, newpath)
+		} else {
+			b.log.Printf("unchanged %s\n", newpath)
+		}
+
+		if err := out.add(newpath, time.Time{}, ManifestEntry{Source: file.Source}); err != nil {
+			return nil, err
+		}
+	}
+
+	if !b.opts.NoSectionList {
+		err := writeSections(result.Sections, out, b.langs, destination, ext, b.opts.SectionOnRoot, b.log)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if b.opts.Sitemap {
+		for _, sitemapFile := range []string{"sitemap-" + ext + ".xml", "sitemap-" + ext + ".txt"} {
+			fullpath := filepath.Join(destination, sitemapFile)
+
+			write := out.sitemap.writeXML
+			if filepath.Ext(sitemapFile) == ".txt" {
+				write = out.sitemap.writeText
+			}
+
+			if err := write(fullpath); err != nil {
+				return nil, fmt.Errorf("cannot write sitemap %s: %w", fullpath, err)
+			}
+
+			b.log.Printf("written sitemap %s (%d entries)\n", fullpath, len(out.sitemap.Entries))
+			out.manifest.Add(ManifestEntry{Path: sitemapFile})
+		}
+	}
+
+	return out, nil
+}
+
+// writeManifest removes or keeps tracking the outputs of previous builds that weren't written again
+// and writes the manifest, it returns the manifest and the removed files.
+func (b *builder) writeManifest(out *output) (*Manifest, []ManifestEntry, error) {
+	previous, err := readManifest(b.opts.Manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var removed []ManifestEntry
+
+	if b.opts.Clean {
+		for _, orphan := range out.manifest.Orphans(previous) {
+			ok, err := removeOrphan(b.opts.Destination, orphan, b.log)
+			if err != nil {
+				return nil, nil, fmt.Errorf("cannot remove stale file %s: %w", orphan.Path, err)
+			}
+
+			if !ok {
+				b.log.Printf("keeping stale %s, it changed since it was written\n", orphan.Path)
+				continue
+			}
+
+			b.log.Printf("removed stale %s\n", orphan.Path)
+			removed = append(removed, orphan)
+		}
+	} else {
+		// keep tracking stale files so a later clean run can remove them
+		for _, orphan := range out.manifest.Orphans(previous) {
+			orphan.Stale = true
+			out.manifest.Add(orphan)
+		}
+	}
+
+	if err := out.manifest.write(b.opts.Manifest); err != nil {
+		return nil, nil, fmt.Errorf("cannot write manifest %s: %w", b.opts.Manifest, err)
+	}
+
+	b.log.Printf("written manifest %s (%d files)\n", b.opts.Manifest, len(out.manifest.Files))
+
+	return &out.manifest, removed, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// output collects every file written to the destination for the sitemap and the manifest.
+type output struct {
+	destination string
+	sitemap     Sitemap
+	manifest    Manifest
+}
+
+func (out *output) add(fullpath string, lastmod time.Time, entry ManifestEntry) error {
+	rel, err := out.track(fullpath, entry)
+	if err != nil {
+		return err
+	}
+
+	out.sitemap.Add(rel, lastmod)
+
+	return nil
+}
+
+// track records the file in the manifest only, it returns the path relative to the destination.
+func (out *output) track(fullpath string, entry ManifestEntry) (string, error) {
+	rel, err := filepath.Rel(out.destination, fullpath)
+	if err != nil {
+		return "", fmt.Errorf("output: rel path for %s: %w", fullpath, err)
+	}
+
+	entry.Path = rel
+	out.manifest.Add(entry)
+
+	return rel, nil
+}
+
+// aggregateSections groups the pages of the tree into their section listings, keyed by the section
+// in the output directory of the page language.
+func aggregateSections(tree *FileTree, langs languages, layout layouts, destination, ext, sort string,
+	uglyURLs bool) (map[string]*Section, error) {
+	sections := make(map[string]*Section)
+
+	for _, file := range tree.Files {
+		// not a section
+		if file.Parent == "." {
+			continue
+		}
+
+		name := filepath.Join(langs.dir(file.Lang), file.Parent)
+		sectionFile := filepath.Join(destination, name, "index."+ext)
+
+		link := pageLink(file.Destination, ext, uglyURLs, file.home())
+
+		if _, ok := sections[name]; !ok {
+			tmpl, err := layout.lookup("li", file.Parent)
+			if err != nil {
+				return nil, fmt.Errorf("section %s list entry layout: %w", name, err)
+			}
+
+			sections[name] = &Section{
+				File:     sectionFile,
+				Source:   filepath.Join(file.Root, file.Parent),
+				Sort:     sort,
+				Template: tmpl,
+			}
+		}
+
+		sections[name].List = append(sections[name].List, SectionEntry{
+			Date:    file.Metadata.Date,
+			Lastmod: file.Metadata.Lastmod,
+			Weight:  file.Metadata.Weight,
+			Title:   file.Metadata.Title,
+			Summary: file.Metadata.Summary,
+			Link:    link,
+
+			WordCount:   file.Metadata.WordCount,
+			ReadingTime: file.Metadata.ReadingTime,
+		})
+	}
+
+	return sections, nil
+}
+
+func writeSections(sections map[string]*Section, out *output, langs languages, destination, ext,
+	seconOnRoot string, log logger) error {
+	for name, section := range sections {
+		// the listing replaces outputs of previous runs unless it belongs to a page
+		if !section.Append {
+			log.Printf("clearing section %s file %s\n", name, section.File)
+			os.Remove(section.File)
+		}
+
+		err := section.write(section.File)
+		if err != nil {
+			return fmt.Errorf("cannot write file %s: %w", section.File, err)
+		}
+
+		log.Printf("written section listing %s to %s\n", name, section.File)
+
+		if err := out.add(section.File, section.Lastmod(), ManifestEntry{Source: section.Source}); err != nil {
+			return err
+		}
+	}
+
+	// each language has its own root
+	for _, dir := range langs.dirs() {
+		section, ok := sections[filepath.Join(dir, seconOnRoot)]
+		if !ok || seconOnRoot == "" {
+			continue
+		}
+
+		sectionFile := filepath.Join(destination, dir, "index."+ext)
+
+		err := section.write(sectionFile)
+		if err != nil {
+			return fmt.Errorf("cannot append to file %s: %w", sectionFile, err)
+		}
+
+		log.Printf("written section listing for root to %s\n", sectionFile)
+
+		if err := out.add(sectionFile, section.Lastmod(), ManifestEntry{Source: section.Source}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
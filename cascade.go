@@ -0,0 +1,131 @@
+package hugoext
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// cascades holds the cascade front matter of the _index files by their language and content
+// directory, hugo pushes these values down to all descendant pages of the same language.
+type cascades map[cascadeKey][]map[string]interface{}
+
+type cascadeKey struct {
+	Lang string
+	Dir  string
+}
+
+// collectCascades reads the cascade front matter of all section _index files.
+func collectCascades(files []File) (cascades, error) {
+	c := make(cascades)
+
+	for _, file := range files {
+		if file.Resource || file.Name != "_index" {
+			continue
+		}
+
+		p, err := parsePage(file.Source)
+		if err != nil {
+			return nil, fmt.Errorf("parse page %s: %w", file.Source, err)
+		}
+
+		meta, err := p.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("page metadata %s: %w", file.Source, err)
+		}
+
+		key := cascadeKey{Lang: file.Lang, Dir: file.Parent}
+
+		for k, v := range meta {
+			if strings.ToLower(k) != "cascade" {
+				continue
+			}
+
+			// either a single map or a list of maps with optional _target
+			switch v := v.(type) {
+			case map[string]interface{}:
+				c[key] = append(c[key], v)
+			case []interface{}:
+				for _, entry := range v {
+					if m, ok := entry.(map[string]interface{}); ok {
+						c[key] = append(c[key], m)
+					}
+				}
+			default:
+				return nil, fmt.Errorf("cascade in %s: unexpected type %T", file.Source, v)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// forPage merges the cascades of all sections above the page, values of nearer sections override
+// those further up. The _index of a section inherits from its parent sections only. Only cascades of
+// the page language apply.
+func (c cascades) forPage(file File) map[string]interface{} {
+	dir := filepath.ToSlash(file.Parent)
+	if file.Name == "_index" {
+		if dir == "." {
+			return nil
+		}
+
+		dir = path.Dir(dir)
+	}
+
+	dirs := []string{"."}
+	if dir != "." {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			dirs = append(dirs, strings.Join(parts[:i+1], "/"))
+		}
+	}
+
+	pagePath := path.Join("/", filepath.ToSlash(file.Parent), file.Name)
+
+	merged := make(map[string]interface{})
+
+	for _, d := range dirs {
+		for _, cascade := range c[cascadeKey{Lang: file.Lang, Dir: filepath.FromSlash(d)}] {
+			if !cascadeTargets(cascade, pagePath) {
+				continue
+			}
+
+			for k, v := range cascade {
+				if strings.ToLower(k) == "_target" {
+					continue
+				}
+
+				merged[strings.ToLower(k)] = v
+			}
+		}
+	}
+
+	return merged
+}
+
+// cascadeTargets reports whether the cascade applies to the page. Of hugo's _target only the path
+// glob is supported, a trailing /** matches everything below.
+func cascadeTargets(cascade map[string]interface{}, pagePath string) bool {
+	var target map[string]interface{}
+
+	for k, v := range cascade {
+		if strings.ToLower(k) == "_target" {
+			target, _ = v.(map[string]interface{})
+		}
+	}
+
+	pattern := stringFromInterface(target["path"])
+	if pattern == "" {
+		return true
+	}
+
+	if prefix := strings.TrimSuffix(pattern, "/**"); prefix != pattern {
+		return pagePath == prefix || strings.HasPrefix(pagePath, prefix+"/")
+	}
+
+	ok, _ := path.Match(pattern, pagePath)
+
+	return ok
+}
@@ -0,0 +1,189 @@
+package hugoext
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gemtextLinkRegexp matches gemtext link lines, the target is the first group.
+var gemtextLinkRegexp = regexp.MustCompile(`^=>[ \t]*(\S+)`)
+
+// BrokenLink is a link in a written file whose local target doesn't exist.
+type BrokenLink struct {
+	File   string
+	Source string
+	Target string
+}
+
+// LinkReport is the result of checking the links of a written tree.
+type LinkReport struct {
+	// Checked is the number of local links.
+	Checked int
+	Broken  []BrokenLink
+}
+
+// CheckLinks verifies the local links of the files with the output extension in the destination
+// without network access. Files are mapped to their source with the manifest if there is one,
+// manifest defaults to .hugoext-<ext>.json in the destination.
+func CheckLinks(destination, ext, manifest string) (*LinkReport, error) {
+	if manifest == "" {
+		manifest = filepath.Join(destination, ".hugoext-"+ext+".json")
+	}
+
+	written, err := readManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make(map[string]string)
+	for _, entry := range written.Files {
+		sources[entry.Path] = entry.Source
+	}
+
+	var report LinkReport
+
+	err = filepath.Walk(destination, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || filepath.Ext(p) != "."+ext {
+			return nil
+		}
+
+		rel, err := filepath.Rel(destination, p)
+		if err != nil {
+			return fmt.Errorf("rel path: %w", err)
+		}
+
+		links, err := fileLinks(p)
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			target, ok := localTarget(rel, link)
+			if !ok {
+				continue
+			}
+
+			report.Checked++
+
+			if !targetExists(destination, target, ext) {
+				report.Broken = append(report.Broken, BrokenLink{
+					File:   rel,
+					Source: sources[filepath.ToSlash(rel)],
+					Target: link,
+				})
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("check walk: %w", err)
+	}
+
+	return &report, nil
+}
+
+// fileLinks returns the targets of gemtext link lines and markdown links outside of preformatted
+// blocks.
+func fileLinks(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	var links []string
+
+	var preformatted bool
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "```") {
+			preformatted = !preformatted
+			continue
+		}
+
+		if preformatted {
+			continue
+		}
+
+		if m := gemtextLinkRegexp.FindStringSubmatch(line); m != nil {
+			links = append(links, m[1])
+			continue
+		}
+
+		for _, m := range markdownLinkRegexp.FindAllStringSubmatch(line, -1) {
+			if m[2] != "" {
+				links = append(links, m[2])
+			} else {
+				links = append(links, m[5])
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan %s: %w", file, err)
+	}
+
+	return links, nil
+}
+
+// localTarget returns the escaped path of a link relative to the destination, it returns false for
+// links with a scheme or host and links to an anchor only.
+func localTarget(from, link string) (string, bool) {
+	u, err := url.Parse(link)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	target := u.EscapedPath()
+	if !strings.HasPrefix(target, "/") {
+		target = path.Join("/", (&url.URL{Path: path.Dir(filepath.ToSlash(from))}).EscapedPath(), target)
+	}
+
+	// keep the trailing slash of directory links
+	if strings.HasSuffix(u.Path, "/") && !strings.HasSuffix(target, "/") {
+		target += "/"
+	}
+
+	return target, true
+}
+
+// targetExists reports whether a link target is written, as the file itself, the index file of a
+// directory or the file of an ugly url like targetPath writes them. Pages are written to the
+// unescaped path, other files may carry the escapes in their name.
+func targetExists(destination, target, ext string) bool {
+	candidates := []string{target}
+	if unescaped, err := url.PathUnescape(target); err == nil && unescaped != target {
+		candidates = append(candidates, unescaped)
+	}
+
+	for _, candidate := range candidates {
+		file := filepath.Join(destination, filepath.FromSlash(candidate))
+
+		files := []string{filepath.Join(file, "index."+ext)}
+		if !strings.HasSuffix(candidate, "/") {
+			files = append(files, file, file+"."+ext)
+		}
+
+		for _, f := range files {
+			if info, err := os.Stat(f); err == nil && !info.IsDir() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
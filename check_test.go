@@ -0,0 +1,75 @@
+package hugoext
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalTarget(t *testing.T) {
+	tests := []struct {
+		from, link string
+		want       string
+		ok         bool
+	}{
+		{"index.gmi", "/posts/a/", "/posts/a/", true},
+		{"posts/a/index.gmi", "../b/", "/posts/b/", true},
+		{"posts/a/index.gmi", "img.png", "/posts/a/img.png", true},
+		{"index.gmi", "/posts/hello-world-über/", "/posts/hello-world-%C3%BCber/", true},
+		{"index.gmi", "/posts/hello-world-%C3%BCber/", "/posts/hello-world-%C3%BCber/", true},
+		{"posts/über/index.gmi", "a.png", "/posts/%C3%BCber/a.png", true},
+		{"index.gmi", "gemini://example.org/", "", false},
+		{"index.gmi", "//example.org/a", "", false},
+		{"index.gmi", "#top", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := localTarget(tt.from, tt.link)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("localTarget(%q, %q) = %q, %v, want %q, %v", tt.from, tt.link, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	dest := t.TempDir()
+
+	files := map[string]string{
+		"index.gmi": "=> /posts/hello-world-%C3%BCber/ escaped\n" +
+			"=> /posts/hello-world-über/ unescaped\n" +
+			"=> /posts/a%20b.txt escaped file name\n" +
+			"=> /posts/missing/ broken\n" +
+			"```\n=> /posts/preformatted/\n```\n",
+		"posts/hello-world-über/index.gmi": "=> ../../ up\n=> /ugly broken\n",
+		"posts/a%20b.txt":                  "",
+	}
+
+	for name, content := range files {
+		file := filepath.Join(dest, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := CheckLinks(dest, "gmi", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Checked != 6 {
+		t.Errorf("checked %d links, want 6", report.Checked)
+	}
+
+	broken := make(map[string]bool)
+	for _, link := range report.Broken {
+		broken[link.Target] = true
+	}
+
+	if len(broken) != 2 || !broken["/posts/missing/"] || !broken["/ugly"] {
+		t.Errorf("broken links %v, want /posts/missing/ and /ugly", report.Broken)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/n0x1m/hugoext"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:]))
+	}
+
+	opts := hugoext.DefaultOptions()
+	opts.Log = os.Stdout
+
+	flag.StringVar(&opts.Ext, "ext", opts.Ext, "ext to look for templates in ./layout")
+	flag.StringVar(&opts.Pipe, "pipe", opts.Pipe, "pipe markdown to this program for content processing")
+	flag.StringVar(&opts.Source, "source", opts.Source, "source directory")
+	flag.StringVar(&opts.Destination, "destination", opts.Destination, "output directory")
+	flag.BoolVar(&opts.Static, "static", false, "mirror the static directory and its static-<ext> override into the destination")
+	flag.StringVar(&opts.StaticDir, "static-dir", opts.StaticDir, "static directory")
+	flag.StringVar(&opts.Config, "config", opts.Config, "hugo config path")
+	flag.BoolVar(&opts.BuildDrafts, "D", false, "include content marked as draft, same as -buildDrafts")
+	flag.BoolVar(&opts.BuildDrafts, "buildDrafts", false, "include content marked as draft")
+	flag.BoolVar(&opts.BuildFuture, "F", false, "include content with publishdate in the future, same as -buildFuture")
+	flag.BoolVar(&opts.BuildFuture, "buildFuture", false, "include content with publishdate in the future")
+	flag.BoolVar(&opts.BuildExpired, "E", false, "include expired content, same as -buildExpired")
+	flag.BoolVar(&opts.BuildExpired, "buildExpired", false, "include expired content")
+	flag.BoolVar(&opts.NoSectionList, "no-section-list", false, "disable auto append of section content lists")
+	flag.StringVar(&opts.SectionOnRoot, "section-on-root", opts.SectionOnRoot, "if append sections, add this one on the root")
+	flag.StringVar(&opts.SectionSort, "section-sort", opts.SectionSort, "sort section lists by date, lastmod, weight or title")
+	flag.BoolVar(&opts.Sitemap, "sitemap", false, "write sitemap-<ext>.xml and sitemap-<ext>.txt of all written files")
+	flag.StringVar(&opts.BaseURL, "baseurl", "", "base url of the output for the sitemap and ref links, the sitemap defaults to baseURL from the hugo config")
+	flag.StringVar(&opts.Manifest, "manifest", "", "manifest of written files, defaults to .hugoext-<ext>.json in the destination")
+	flag.BoolVar(&opts.Clean, "clean", false, "remove files written by a previous run that are no longer generated")
+	flag.StringVar(&opts.OnCollision, "on-collision", opts.OnCollision, "pages with the same destination: fail, suffix or skip")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "print what would be written without creating any files")
+	flag.BoolVar(&opts.DryRunPipe, "dry-run-pipe", false, "dry run that also runs the pipe and shows its output size or error in the plan, implies -dry-run")
+	flag.Parse()
+
+	result, err := hugoext.Build(context.Background(), opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if result.Plan != nil {
+		if err := result.Plan.Print(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// check is the check subcommand, it verifies the local links of the written tree without network
+// access and returns the exit code.
+func check(args []string) int {
+	opts := hugoext.DefaultOptions()
+
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	flags.StringVar(&opts.Ext, "ext", opts.Ext, "ext of the written files to check")
+	flags.StringVar(&opts.Destination, "destination", opts.Destination, "output directory")
+	flags.StringVar(&opts.Manifest, "manifest", "", "manifest to map files to their source, defaults to .hugoext-<ext>.json in the destination")
+	flags.Parse(args)
+
+	fmt.Printf("hugoext: checking links of %v files in %v\n", opts.Ext, opts.Destination)
+
+	report, err := hugoext.CheckLinks(opts.Destination, opts.Ext, opts.Manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, link := range report.Broken {
+		source := link.Source
+		if source == "" {
+			source = "-"
+		}
+
+		fmt.Printf("broken link %s in %s (source %s)\n", link.Target, link.File, source)
+	}
+
+	fmt.Printf("checked %d local links, %d broken\n", report.Checked, len(report.Broken))
+
+	if len(report.Broken) > 0 {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,183 @@
+package hugoext
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// collisionPolicies are the ways to deal with pages that are written to the same file.
+var collisionPolicies = map[string]bool{
+	"fail":   true, // report all collisions and stop
+	"suffix": true, // append -2, -3, ... to the destination of later pages
+	"skip":   true, // keep the first page and skip the others
+}
+
+// resolveCollisions detects pages whose destinations end up in the same output file and applies the
+// policy. Pages are handled in tree order, the first page keeps its destination.
+func resolveCollisions(tree *FileTree, ext string, uglyURLs bool, policy string, log logger) error {
+	outputFile := func(file File) string {
+		dir, filename := targetPath(file.Destination, ext, uglyURLs, file.home())
+		return filepath.Join(".", dir, filename)
+	}
+
+	written := make(map[string]string)
+	files := make([]File, 0, len(tree.Files))
+
+	var collisions []string
+
+	for _, file := range tree.Files {
+		out := outputFile(file)
+
+		first, ok := written[out]
+		if !ok {
+			written[out] = file.Source
+			files = append(files, file)
+
+			continue
+		}
+
+		switch policy {
+		case "suffix":
+			base := file.Destination
+			for i := 2; ok; i++ {
+				file.Destination = suffixDestination(base, i)
+				_, ok = written[outputFile(file)]
+			}
+
+			log.Printf("collision: %s and %s write %s, using %s\n", first, file.Source, out, file.Destination)
+			written[outputFile(file)] = file.Source
+			files = append(files, file)
+		case "skip":
+			log.Printf("collision: %s and %s write %s, skipping %s\n", first, file.Source, out, file.Source)
+			file.Skip = "collision with " + first
+			tree.Skipped = append(tree.Skipped, file)
+		default:
+			collisions = append(collisions, fmt.Sprintf("%s and %s write %s", first, file.Source, out))
+		}
+	}
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("destination collisions:\n  %s", strings.Join(collisions, "\n  "))
+	}
+
+	tree.Files = files
+
+	return nil
+}
+
+// suffixDestination appends -n to the last element of the destination, keeping a trailing slash.
+func suffixDestination(dest string, n int) string {
+	trimmed := strings.TrimSuffix(dest, "/")
+
+	return fmt.Sprintf("%s-%d%s", trimmed, n, dest[len(trimmed):])
+}
+
+// resolveOutputCollisions detects alias stubs, bundle resources, section listings and static files
+// written to the file of a page or of each other. Pages take precedence, the other outputs are
+// claimed in that order. Section listings are appended to a page written to their file. The fail policy reports all collisions, otherwise the later output is
+// skipped as it can't be renamed without breaking links to it.
+func resolveOutputCollisions(result *Result, langs languages, destination, ext string, uglyURLs bool,
+	policy string, log logger) error {
+	tree := &result.Tree
+
+	outputFile := func(dest string, home bool) string {
+		dir, filename := targetPath(dest, ext, uglyURLs, home)
+		return filepath.Join(".", dir, filename)
+	}
+
+	written := make(map[string]string)
+	pages := make(map[string]bool)
+
+	for _, file := range tree.Files {
+		out := outputFile(file.Destination, file.home())
+		written[out], pages[out] = file.Source, true
+	}
+
+	var collisions []string
+
+	// claim reports whether the output file is still free and takes it
+	claim := func(out, source, what string) bool {
+		first, ok := written[out]
+		if !ok {
+			written[out] = source
+			return true
+		}
+
+		if policy == "fail" {
+			collisions = append(collisions, fmt.Sprintf("%s of %s and %s write %s", what, source, first, out))
+		} else {
+			log.Printf("collision: %s of %s and %s write %s, skipping %s\n", what, source, first, out, what)
+		}
+
+		return false
+	}
+
+	for i := range tree.Files {
+		file := &tree.Files[i]
+
+		var aliases []string
+		for _, alias := range file.Metadata.Aliases {
+			if claim(outputFile(aliasDestination(file, alias, langs.dir(file.Lang)), false), file.Source,
+				"alias "+alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+
+		file.Metadata.Aliases = aliases
+	}
+
+	resources := tree.Resources[:0]
+	for _, file := range tree.Resources {
+		if !claim(filepath.Join(".", file.Destination), file.Source, "resource") {
+			file.Skip = "collision with " + written[filepath.Join(".", file.Destination)]
+			tree.Skipped = append(tree.Skipped, file)
+
+			continue
+		}
+
+		resources = append(resources, file)
+	}
+
+	tree.Resources = resources
+
+	names := make([]string, 0, len(result.Sections))
+	for name := range result.Sections {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		rel, err := filepath.Rel(destination, result.Sections[name].File)
+		if err != nil {
+			return fmt.Errorf("rel path: %w", err)
+		}
+
+		// like the root listing, a listing is appended to the page written there
+		if pages[rel] {
+			result.Sections[name].Append = true
+			continue
+		}
+
+		if !claim(rel, name, "section listing") {
+			delete(result.Sections, name)
+		}
+	}
+
+	static := result.Static[:0]
+	for _, file := range result.Static {
+		if claim(filepath.Join(".", file.Destination), file.Source, "static file") {
+			static = append(static, file)
+		}
+	}
+
+	result.Static = static
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("destination collisions:\n  %s", strings.Join(collisions, "\n  "))
+	}
+
+	return nil
+}
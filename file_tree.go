@@ -1,42 +1,83 @@
-package main
+package hugoext
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/n0x1m/hugoext/hugo"
 )
 
+// contentExtensions are the file types hugo renders as pages, everything else is a resource.
+var contentExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".mdown":    true,
+	".mkd":      true,
+	".mkdn":     true,
+	".html":     true,
+	".htm":      true,
+	".org":      true,
+	".ad":       true,
+	".adoc":     true,
+	".asciidoc": true,
+	".pdc":      true,
+	".pandoc":   true,
+	".rst":      true,
+}
+
 type FileTree struct {
-	Files []File
+	Files     []File
+	Resources []File
+	// Skipped are unpublished pages and resources of unpublished bundles.
+	Skipped []File
 }
 
 type File struct {
-	Root        string
-	Source      string
+	Root   string
+	Source string
+	// Destination is the unescaped path in the output tree, links to it are escaped.
 	Destination string
 	Parent      string
 	Name        string
 	Extension   string
 	Draft       bool
+	// Skip is the reason the file isn't published, if any.
+	Skip string
+
+	// Resource marks files that are copied verbatim instead of processed as a page.
+	Resource bool
+	// Bundle is the source directory of the leaf bundle a page or resource belongs to.
+	Bundle string
+
+	// Lang is the language of the page, from the file name suffix or its content directory.
+	Lang string
+	// Translations are the same page in the other languages.
+	Translations []Translation
 
 	Metadata hugo.PageMetadata
 	Body     []byte
 	NewBody  []byte
 }
 
-func (file *File) Write(dest, newext string, uglyURLs bool) (string, error) {
-	outdir, outfile := targetPath(file.Destination, newext, uglyURLs)
+// home reports whether the file is the home page of the site or of a language.
+func (file *File) home() bool {
+	return !file.Resource && file.Name == "_index" && file.Parent == "." && file.Metadata.URL == ""
+}
+
+func (file *File) write(dest, newext string, uglyURLs bool, log logger) (string, error) {
+	outdir, outfile := targetPath(file.Destination, newext, uglyURLs, file.home())
 
 	// ensure directory exists
 	newdir := filepath.Join(dest, outdir)
 	if made, err := mkdir(newdir); err != nil {
 		return "", err
 	} else if made {
-		fmt.Printf("mkdir %s\n", newdir)
+		log.Printf("mkdir %s\n", newdir)
 	}
 
 	fullpath := filepath.Join(newdir, outfile)
@@ -55,6 +96,28 @@ func (file *File) Write(dest, newext string, uglyURLs bool) (string, error) {
 	return fullpath, nil
 }
 
+// publishConfig controls which pages are published, from the site config and command line.
+type publishConfig struct {
+	Drafts  bool
+	Future  bool
+	Expired bool
+}
+
+// skip returns why the page is not published at the given time like hugo decides it, or an empty
+// string.
+func (cfg publishConfig) skip(m *hugo.PageMetadata, now time.Time) string {
+	switch {
+	case m.Draft && !cfg.Drafts:
+		return "draft"
+	case m.PublishDate.After(now) && !cfg.Future:
+		return "future"
+	case !m.ExpiryDate.IsZero() && m.ExpiryDate.Before(now) && !cfg.Expired:
+		return "expired"
+	}
+
+	return ""
+}
+
 func parsePage(fullpath string) (hugo.Page, error) {
 	file, err := os.Open(fullpath)
 	if err != nil {
@@ -70,35 +133,87 @@ func parsePage(fullpath string) (hugo.Page, error) {
 	return page, nil
 }
 
-func parseMetadata(page hugo.Page) (*hugo.PageMetadata, error) {
+func parseMetadata(page hugo.Page, src pageSource, cfg metadataConfig,
+	cascade map[string]interface{}) (*hugo.PageMetadata, error) {
 	meta, err := page.Metadata()
 	if err != nil {
 		return nil, fmt.Errorf("page metadata: %w", err)
 	}
 
-	c := NewContentFromMeta(meta)
+	// cascaded values apply where the page doesn't set them
+	if len(cascade) > 0 {
+		merged := make(map[string]interface{}, len(cascade)+len(meta))
+		for k, v := range cascade {
+			merged[k] = v
+		}
+
+		for k, v := range meta {
+			merged[strings.ToLower(k)] = v
+		}
+
+		meta = merged
+	}
+
+	c, err := newContentFromMeta(meta, src, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("front matter: %w", err)
+	}
 
 	return c, nil
 }
 
-func destinationPath(file *File, pattern string) error {
+func destinationPath(file *File, pattern string, opts hugo.PathOptions, cfg metadataConfig,
+	cascade map[string]interface{}) error {
 	p, err := parsePage(file.Source)
 	if err != nil {
 		return fmt.Errorf("parse page: %w", err)
 	}
 
+	info, err := os.Stat(file.Source)
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	src := pageSource{Filename: file.Name, ModTime: info.ModTime()}
+
+	if cfg.GitLastmod != nil {
+		real, err := realPath(file.Source)
+		if err != nil {
+			return err
+		}
+
+		src.GitLastmod = cfg.GitLastmod[real]
+	}
+
 	// create content
-	c, err := parseMetadata(p)
+	c, err := parseMetadata(p, src, cfg, cascade)
 	if err != nil {
 		return fmt.Errorf("parse metadata: %w", err)
 	}
 
 	c.Filepath = file.Name
+	c.Lang = file.Lang
 
 	if file.Parent != "." {
-		link, err := hugo.PathPattern(pattern).Expand(c)
+		c.Sections = strings.Split(filepath.ToSlash(file.Parent), "/")
+		c.Subdir = c.Sections[0]
+	}
+
+	if c.URL != "" {
+		// the url front matter overrides the permalink
+		file.Destination = trimURLExt(path.Join("/", c.URL))
+		if strings.HasSuffix(c.URL, "/") {
+			file.Destination += "/"
+		}
+	} else if file.Parent != "." {
+		link, err := hugo.PathPattern(pattern).Expand(c, opts)
 		if err != nil {
-			return fmt.Errorf("hugo pathpattern: %w", err)
+			return fmt.Errorf("expand permalink: %w", err)
+		}
+
+		// hugo writes to the unescaped permalink, links escape it again
+		if unescaped, err := url.QueryUnescape(link); err == nil {
+			link = unescaped
 		}
 
 		file.Destination = link
@@ -106,22 +221,120 @@ func destinationPath(file *File, pattern string) error {
 		file.Destination = strings.TrimLeft(file.Name, "_")
 	}
 
+	// derive the summary before piping so listings always have text
+	content := stripShortcodes(p.Content())
+	if c.Summary == "" {
+		c.Summary = summarize(content, cfg.SummaryLength)
+	}
+
+	if _, ok := c.Params["iscjklanguage"]; !ok && cfg.HasCJKLanguage {
+		c.IsCJKLanguage = cjkRegexp.Match(content)
+	}
+
+	c.WordCount, c.ReadingTime = countWords(content, c.IsCJKLanguage)
+
 	file.Draft = c.Draft
 	file.Metadata = *c
-	file.Body = p.Body()
+	file.Body = removeSummaryDivider(p.Body())
 
 	return nil
 }
 
-func collectFiles(fullpath string, filechan chan File) error {
+// resolve returns the resource at its destinations. Bundle resources are placed next to each
+// translation of the page they belong to, all others keep their path relative to the content root.
+func (file File) resolve(bundles map[string][]string) []File {
+	if file.Bundle == "" {
+		return []File{file}
+	}
+
+	var resolved []File
+	for _, dir := range bundles[file.Bundle] {
+		resource := file
+		resource.Destination = filepath.Join(dir, file.Destination)
+		resolved = append(resolved, resource)
+	}
+
+	return resolved
+}
+
+// copy writes the resource unmodified to its destination.
+func (file *File) copy(dest string, log logger) (string, error) {
+	fullpath := filepath.Join(dest, file.Destination)
+
+	newdir := filepath.Dir(fullpath)
+	if made, err := mkdir(newdir); err != nil {
+		return "", err
+	} else if made {
+		log.Printf("mkdir %s\n", newdir)
+	}
+
+	return fullpath, copyFile(file.Source, fullpath)
+}
+
+// isLeafBundle reports whether dir contains an index content file in any language.
+func isLeafBundle(dir string, langs languages) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range entries {
+		filename := entry.Name()
+		ext := path.Ext(filename)
+
+		name, _ := langs.split(filename[0:len(filename)-len(ext)], "")
+		if !entry.IsDir() && contentExtensions[ext] && name == "index" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectContent sends the files of the source and all language content directories.
+func collectContent(source string, langs languages, filechan chan File, log logger) error {
 	defer close(filechan)
 
+	dirs := langs.contentDirs(source)
+
+	for _, dir := range sortedKeys(dirs) {
+		lang := dirs[dir]
+		if lang == "" {
+			lang = langs.Default
+		}
+
+		if err := collectFiles(dir, lang, langs, dirs, filechan, log); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectFiles walks a content directory in which pages are of lang unless their file name has a
+// language suffix. Nested content directories of other languages are left out.
+func collectFiles(fullpath, lang string, langs languages, contentDirs map[string]string,
+	filechan chan File, log logger) error {
+	// the leaf bundle we're currently walking, all files below it belong to its index page
+	var bundle string
+
 	err := filepath.Walk(fullpath,
 		func(p string, info os.FileInfo, err error) error {
 			if err != nil {
 				return err
 			}
+
+			inBundle := bundle != "" && strings.HasPrefix(p, bundle+string(filepath.Separator))
+
 			if info.IsDir() {
+				if _, ok := contentDirs[filepath.Clean(p)]; ok && p != fullpath {
+					return filepath.SkipDir
+				}
+
+				if !inBundle && p != fullpath && isLeafBundle(p, langs) {
+					bundle = p
+				}
+
 				return nil
 			}
 
@@ -135,12 +348,63 @@ func collectFiles(fullpath string, filechan chan File) error {
 			name := filename[0 : len(filename)-len(ext)]
 			parent := filepath.Dir(rel)
 
-			filechan <- File{
-				Root:      fullpath,
-				Source:    p,
-				Name:      name,
-				Extension: ext,
-				Parent:    parent,
+			pageLang := lang
+			if contentExtensions[ext] {
+				name, pageLang = langs.split(name, lang)
+			}
+
+			if !inBundle {
+				filechan <- File{
+					Root:        fullpath,
+					Source:      p,
+					Destination: rel,
+					Name:        name,
+					Extension:   ext,
+					Parent:      parent,
+					Resource:    !contentExtensions[ext],
+					Lang:        pageLang,
+				}
+
+				return nil
+			}
+
+			bundleRel, err := filepath.Rel(bundle, p)
+			if err != nil {
+				return fmt.Errorf("rel path: %w", err)
+			}
+
+			switch {
+			case contentExtensions[ext] && bundleRel == filename && name == "index":
+				// the bundle page is named after its directory
+				bundleDir, err := filepath.Rel(fullpath, bundle)
+				if err != nil {
+					return fmt.Errorf("rel path: %w", err)
+				}
+
+				filechan <- File{
+					Root:      fullpath,
+					Source:    p,
+					Name:      filepath.Base(bundleDir),
+					Extension: ext,
+					Parent:    filepath.Dir(bundleDir),
+					Bundle:    bundle,
+					Lang:      pageLang,
+				}
+			case contentExtensions[ext]:
+				// hugo doesn't publish content files inside a leaf bundle
+				log.Printf("skipping bundle content %s\n", p)
+			default:
+				filechan <- File{
+					Root:        fullpath,
+					Source:      p,
+					Destination: bundleRel,
+					Name:        name,
+					Extension:   ext,
+					Parent:      parent,
+					Resource:    true,
+					Bundle:      bundle,
+					Lang:        pageLang,
+				}
 			}
 
 			return nil
@@ -0,0 +1,82 @@
+package hugoext
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitLastmod reads the history of the git repository containing dir and returns the author date of
+// the last commit that touched each file below dir, keyed by absolute path with symlinks resolved.
+func gitLastmod(dir string) (map[string]time.Time, error) {
+	abs, err := realPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	top, err := exec.Command("git", "-C", abs, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse: %w", err)
+	}
+
+	root, err := realPath(strings.TrimSpace(string(top)))
+	if err != nil {
+		return nil, err
+	}
+
+	// each commit starts with a record separator and its date followed by the changed files
+	out, err := exec.Command("git", "-C", root, "-c", "core.quotepath=false", "log",
+		"--name-only", "--no-merges", "--format=%x1e%aI", "--", abs).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	lastmod := make(map[string]time.Time)
+
+	for _, commit := range bytes.Split(out, []byte{0x1e}) {
+		scanner := bufio.NewScanner(bytes.NewReader(commit))
+		if !scanner.Scan() {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("git log date: %w", err)
+		}
+
+		for scanner.Scan() {
+			name := strings.TrimSpace(scanner.Text())
+			if name == "" {
+				continue
+			}
+
+			// the log is newest first, keep the first date seen
+			file := filepath.Join(root, filepath.FromSlash(name))
+			if _, ok := lastmod[file]; !ok {
+				lastmod[file] = date
+			}
+		}
+	}
+
+	return lastmod, nil
+}
+
+// realPath returns the absolute path of file with all symlinks resolved, git reports the repository
+// root that way while the content directory may be reached through a link.
+func realPath(file string) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", fmt.Errorf("abs path: %w", err)
+	}
+
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("resolve symlinks: %w", err)
+	}
+
+	return real, nil
+}
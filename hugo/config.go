@@ -2,39 +2,138 @@ package hugo
 
 import (
 	"fmt"
+	"io"
+	"sort"
+	"strings"
 
+	"github.com/gohugoio/hugo/common/maps"
 	hugoconfig "github.com/gohugoio/hugo/config"
 	"github.com/spf13/afero"
+	"github.com/spf13/cast"
 )
 
 type Config struct {
+	// Path of the config file, config.toml if empty.
+	Path string
+	// Log receives the settings that fall back to their default, nothing is written without one.
+	Log io.Writer
+
 	hugoconfig hugoconfig.Provider
 }
 
-func (c *Config) read() hugoconfig.Provider {
-	if c.hugoconfig == nil {
-		cfg, err := hugoconfig.FromFile(afero.NewOsFs(), "config.toml")
-		if err != nil {
-			fmt.Println("load config from file failed", err)
-			return nil
-		}
-		c.hugoconfig = cfg
+// Load reads the config file, it's read on first use of a getter otherwise. Getters of a config that
+// can't be loaded return the defaults.
+func (c *Config) Load() error {
+	if c.hugoconfig != nil {
+		return nil
+	}
+
+	path := c.Path
+	if path == "" {
+		path = "config.toml"
+	}
+
+	cfg, err := hugoconfig.FromFile(afero.NewOsFs(), path)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	c.hugoconfig = cfg
+
+	return nil
+}
+
+// lookup returns the loaded config or nil, it reports if v is not set.
+func (c *Config) lookup(v string) hugoconfig.Provider {
+	if err := c.Load(); err != nil {
+		c.logf("config: %v\n", err)
 	}
+
+	if c.hugoconfig == nil || !c.hugoconfig.IsSet(v) {
+		c.logf("config: no %v set, using default\n", v)
+	}
+
 	return c.hugoconfig
 }
 
+func (c *Config) logf(format string, args ...interface{}) {
+	if c.Log != nil {
+		fmt.Fprintf(c.Log, format, args...)
+	}
+}
+
 func (c *Config) GetBool(v string) bool {
-	cfg := c.read()
-	if cfg == nil || !cfg.IsSet(v) {
-		fmt.Printf("config: no %v set, using default\n", v)
+	if cfg := c.lookup(v); cfg != nil {
+		return cfg.GetBool(v)
 	}
-	return cfg.GetBool(v)
+	return false
 }
 
 func (c *Config) GetStringMapString(v string) map[string]string {
-	cfg := c.read()
-	if cfg == nil || !cfg.IsSet(v) {
-		fmt.Printf("config: no %v set, using default\n", v)
+	if cfg := c.lookup(v); cfg != nil {
+		return cfg.GetStringMapString(v)
+	}
+	return nil
+}
+
+func (c *Config) GetStringMap(v string) map[string]interface{} {
+	if cfg := c.lookup(v); cfg != nil {
+		return cfg.GetStringMap(v)
 	}
-	return cfg.GetStringMapString(v)
+	return nil
+}
+
+func (c *Config) GetInt(v string) int {
+	if cfg := c.lookup(v); cfg != nil {
+		return cfg.GetInt(v)
+	}
+	return 0
+}
+
+func (c *Config) GetString(v string) string {
+	if cfg := c.lookup(v); cfg != nil {
+		return cfg.GetString(v)
+	}
+	return ""
+}
+
+// Language is a site language from the languages config.
+type Language struct {
+	Lang         string
+	LanguageName string
+	Title        string
+	ContentDir   string
+	Weight       int
+}
+
+// GetLanguages returns the configured languages ordered by weight and language code.
+func (c *Config) GetLanguages() []Language {
+	var languages []Language
+
+	for lang, v := range c.GetStringMap("languages") {
+		var params map[string]interface{}
+		switch v := v.(type) {
+		case maps.Params:
+			params = v
+		case map[string]interface{}:
+			params = v
+		}
+
+		languages = append(languages, Language{
+			Lang:         strings.ToLower(lang),
+			LanguageName: cast.ToString(params["languagename"]),
+			Title:        cast.ToString(params["title"]),
+			ContentDir:   cast.ToString(params["contentdir"]),
+			Weight:       cast.ToInt(params["weight"]),
+		})
+	}
+
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].Weight != languages[j].Weight {
+			return languages[i].Weight < languages[j].Weight
+		}
+
+		return languages[i].Lang < languages[j].Lang
+	})
+
+	return languages
 }
@@ -0,0 +1,93 @@
+package hugo
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/gohugoio/hugo/common/text"
+)
+
+// PathOptions are the site settings hugo applies when turning page attributes into paths.
+type PathOptions struct {
+	// RemovePathAccents strips diacritics, e.g. "é" becomes "e".
+	RemovePathAccents bool
+	// DisablePathToLower keeps the case of the original.
+	DisablePathToLower bool
+}
+
+// URLize sanitizes a string for use in a path and escapes it like hugo's urlize, spaces become
+// hyphens and disallowed characters are removed.
+func (opts PathOptions) URLize(uri string) string {
+	return opts.urlEscape(opts.MakePathSanitized(uri))
+}
+
+// MakePathSanitized sanitizes the string and lower cases it unless disabled.
+func (opts PathOptions) MakePathSanitized(s string) string {
+	if opts.DisablePathToLower {
+		return opts.MakePath(s)
+	}
+
+	return strings.ToLower(opts.MakePath(s))
+}
+
+// MakePath sanitizes the string for use in a path.
+func (opts PathOptions) MakePath(s string) string {
+	return opts.UnicodeSanitize(s)
+}
+
+// UnicodeSanitize is hugo's v0.85 path sanitizer. It keeps letters, digits, marks, the characters
+// . / \ _ # + ~ and valid percent escapes. Runs of spaces and hyphens between them become a single
+// hyphen, leading ones are dropped.
+func (opts PathOptions) UnicodeSanitize(s string) string {
+	if opts.RemovePathAccents {
+		s = text.RemoveAccentsString(s)
+	}
+
+	source := []rune(s)
+	target := make([]rune, 0, len(source))
+
+	var prependHyphen bool
+
+	for i, r := range source {
+		isAllowed := r == '.' || r == '/' || r == '\\' || r == '_' || r == '#' || r == '+' || r == '~'
+		isAllowed = isAllowed || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+		isAllowed = isAllowed || (r == '%' && i+2 < len(source) && ishex(source[i+1]) && ishex(source[i+2]))
+
+		if isAllowed {
+			if prependHyphen {
+				target = append(target, '-')
+				prependHyphen = false
+			}
+
+			target = append(target, r)
+		} else if len(target) > 0 && (r == '-' || unicode.IsSpace(r)) {
+			prependHyphen = true
+		}
+	}
+
+	return string(target)
+}
+
+func (opts PathOptions) urlEscape(uri string) string {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		// handle it as a slug
+		return opts.MakePath(uri)
+	}
+
+	return parsedURI.String()
+}
+
+func ishex(c rune) bool {
+	switch {
+	case '0' <= c && c <= '9':
+		return true
+	case 'a' <= c && c <= 'f':
+		return true
+	case 'A' <= c && c <= 'F':
+		return true
+	}
+
+	return false
+}
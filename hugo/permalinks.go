@@ -3,6 +3,7 @@ package hugo
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -10,40 +11,70 @@ import (
 )
 
 type PageMetadata struct {
-	Title      string
-	Slug       string
-	Summary    string
-	Categories []string
-	Tags       []string
-	Date       time.Time
-	Draft      bool
+	Title      string    `json:"title"`
+	Slug       string    `json:"slug,omitempty"`
+	Summary    string    `json:"summary,omitempty"`
+	Categories []string  `json:"categories,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	Date       time.Time `json:"date"`
+	Lastmod    time.Time `json:"lastmod"`
+	Draft      bool      `json:"draft,omitempty"`
 
-	Filepath  string
-	Subdir    string
-	Permalink string
+	PublishDate time.Time `json:"publishDate"`
+	ExpiryDate  time.Time `json:"expiryDate"`
+	Weight      int       `json:"weight,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Aliases     []string  `json:"aliases,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Type        string    `json:"type,omitempty"`
+	Layout      string    `json:"layout,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"`
+	Headless    bool      `json:"headless,omitempty"`
+	Lang        string    `json:"lang,omitempty"`
+
+	IsCJKLanguage bool `json:"isCJKLanguage,omitempty"`
+	WordCount     int  `json:"wordCount"`
+	// ReadingTime is the estimated reading time in minutes.
+	ReadingTime int `json:"readingTime"`
+
+	// Params holds all front matter with lower case keys like hugo's .Params.
+	Params map[string]interface{} `json:"params,omitempty"`
+
+	Filepath  string `json:"filepath"`
+	Subdir    string `json:"subdir,omitempty"`
+	Permalink string `json:"permalink,omitempty"`
+	// Sections are the content directories the page is in, from the top level section down.
+	Sections []string `json:"sections,omitempty"`
 }
 
 func init() {
 	knownPermalinkAttributes = map[string]pageToPermaAttribute{
-		"year":        pageToPermalinkDate,
-		"month":       pageToPermalinkDate,
-		"monthname":   pageToPermalinkDate,
-		"day":         pageToPermalinkDate,
-		"weekday":     pageToPermalinkDate,
-		"weekdayname": pageToPermalinkDate,
-		"yearday":     pageToPermalinkDate,
-		"section":     pageToPermalinkSection,
-		"title":       pageToPermalinkTitle,
-		"slug":        pageToPermalinkSlugElseTitle,
-		"filename":    pageToPermalinkFilename,
+		"year":            pageToPermalinkDate,
+		"month":           pageToPermalinkDate,
+		"monthname":       pageToPermalinkDate,
+		"day":             pageToPermalinkDate,
+		"weekday":         pageToPermalinkDate,
+		"weekdayname":     pageToPermalinkDate,
+		"yearday":         pageToPermalinkDate,
+		"section":         pageToPermalinkSection,
+		"title":           pageToPermalinkTitle,
+		"slug":            pageToPermalinkSlugElseTitle,
+		"slugorfilename":  pageToPermalinkSlugElseFilename,
+		"filename":        pageToPermalinkFilename,
+		"contentbasename": pageToPermalinkFilename,
+		"sections":        pageToPermalinkSections,
 	}
 
-	attributeRegexp = regexp.MustCompile(`:\w+`)
+	// date formats like :2006-01-02 or attributes with an optional slice like :sections[1:]
+	attributeRegexp = regexp.MustCompile(`:\d[\w\-.]*|:\w+(\[.+?\])?`)
 }
 
+// referenceTime detects go time layouts used as permalink attributes.
+var referenceTime = time.Date(2019, time.November, 9, 23, 1, 42, 1, time.UTC)
+
 // pageToPermaAttribute is the type of a function which, given a page and a tag
-// can return a string to go in that position in the page (or an error)
-type pageToPermaAttribute func(*PageMetadata, string) (string, error)
+// can return a string to go in that position in the page (or an error), sanitized per the options
+type pageToPermaAttribute func(*PageMetadata, string, PathOptions) (string, error)
 
 // PathPattern represents a string which builds up a URL from attributes
 type PathPattern string
@@ -55,13 +86,41 @@ var knownPermalinkAttributes map[string]pageToPermaAttribute
 
 var attributeRegexp *regexp.Regexp
 
-// validate determines if a PathPattern is well-formed
-func (pp PathPattern) validate() bool {
+// lookupPermalinkAttribute returns the function for a :tag without colon, the known attributes, a
+// sliced :sections[...] or any go time layout.
+func lookupPermalinkAttribute(attr string) (pageToPermaAttribute, bool) {
+	if callback, ok := knownPermalinkAttributes[strings.ToLower(attr)]; ok {
+		return callback, true
+	}
+
+	if strings.HasPrefix(strings.ToLower(attr), "sections[") {
+		slice := toSliceFunc(attr[len("sections"):])
+
+		return func(m *PageMetadata, _ string, opts PathOptions) (string, error) {
+			return urlizeSections(slice(m.Sections), opts), nil
+		}, true
+	}
+
+	// make sure this comes after all the other checks
+	if referenceTime.Format(attr) != attr {
+		return pageToPermalinkDateFormat, true
+	}
+
+	return nil, false
+}
+
+// Validate determines if a PathPattern is well-formed, it returns an error naming the pattern and
+// the empty path segment or unknown attribute otherwise.
+func (pp PathPattern) Validate() error {
+	if len(pp) == 0 {
+		return fmt.Errorf("empty permalink pattern")
+	}
+
 	fragments := strings.Split(string(pp[1:]), "/")
 	var bail = false
 	for i := range fragments {
 		if bail {
-			return false
+			return fmt.Errorf("permalink pattern %q: empty path segment", pp)
 		}
 		if len(fragments[i]) == 0 {
 			bail = true
@@ -74,20 +133,19 @@ func (pp PathPattern) validate() bool {
 		}
 
 		for _, match := range matches {
-			k := strings.ToLower(match[0][1:])
-			if _, ok := knownPermalinkAttributes[k]; !ok {
-				return false
+			if _, ok := lookupPermalinkAttribute(match[0][1:]); !ok {
+				return fmt.Errorf("permalink pattern %q: unknown attribute %s", pp, match[0])
 			}
 		}
 	}
-	return true
+	return nil
 }
 
 // Expand on a PathPattern takes a Content and returns the fully expanded Permalink
-// or an error explaining the failure.
-func (pp PathPattern) Expand(m *PageMetadata) (string, error) {
-	if !pp.validate() {
-		return "", fmt.Errorf("error")
+// or an error explaining the failure. Attribute values are sanitized like hugo's urlize.
+func (pp PathPattern) Expand(m *PageMetadata, opts PathOptions) (string, error) {
+	if err := pp.Validate(); err != nil {
+		return "", err
 	}
 	sections := strings.Split(string(pp), "/")
 	for i, field := range sections {
@@ -105,16 +163,16 @@ func (pp PathPattern) Expand(m *PageMetadata) (string, error) {
 
 		for _, match := range matches {
 			attr := match[0][1:]
-			callback, ok := knownPermalinkAttributes[attr]
+			callback, ok := lookupPermalinkAttribute(attr)
 
 			if !ok {
-				return "", fmt.Errorf("err2")
+				return "", fmt.Errorf("permalink pattern %q: unknown attribute %s", pp, match[0])
 			}
 
-			newAttr, err := callback(m, attr)
+			newAttr, err := callback(m, attr, opts)
 
 			if err != nil {
-				return "", fmt.Errorf("err3 %w", err)
+				return "", fmt.Errorf("permalink pattern %q: attribute %s of %q: %w", pp, match[0], m.Filepath, err)
 			}
 
 			newField = strings.Replace(newField, match[0], newAttr, 1)
@@ -125,7 +183,7 @@ func (pp PathPattern) Expand(m *PageMetadata) (string, error) {
 	return strings.Join(sections, "/"), nil
 }
 
-func pageToPermalinkDate(m *PageMetadata, dateField string) (string, error) {
+func pageToPermalinkDate(m *PageMetadata, dateField string, opts PathOptions) (string, error) {
 	// a Content contains a Node which provides a field Date, time.Time
 	switch dateField {
 	case "year":
@@ -148,8 +206,13 @@ func pageToPermalinkDate(m *PageMetadata, dateField string) (string, error) {
 	panic("coding error: should not be here")
 }
 
+// pageToPermalinkDateFormat formats the page date with the attribute as go time layout.
+func pageToPermalinkDateFormat(m *PageMetadata, layout string, opts PathOptions) (string, error) {
+	return m.Date.Format(layout), nil
+}
+
 // if the page has a slug, return the slug, else return the title
-func pageToPermalinkSlugElseTitle(m *PageMetadata, a string) (string, error) {
+func pageToPermalinkSlugElseTitle(m *PageMetadata, a string, opts PathOptions) (string, error) {
 	if m.Slug != "" {
 		// Don't start or end with a -
 		// TODO(bep) this doesn't look good... Set the Slug once.
@@ -160,22 +223,135 @@ func pageToPermalinkSlugElseTitle(m *PageMetadata, a string) (string, error) {
 		if strings.HasSuffix(m.Slug, "-") {
 			m.Slug = m.Slug[0 : len(m.Slug)-1]
 		}
-		return URLEscape(m.Slug)
+		return opts.URLize(m.Slug), nil
 	}
-	return pageToPermalinkTitle(m, a)
+	return pageToPermalinkTitle(m, a, opts)
+}
+
+// pageToPermalinkSlugElseFilename returns the slug if set, else the filename
+func pageToPermalinkSlugElseFilename(m *PageMetadata, a string, opts PathOptions) (string, error) {
+	if m.Slug != "" {
+		return pageToPermalinkSlugElseTitle(m, a, opts)
+	}
+
+	return pageToPermalinkFilename(m, a, opts)
 }
 
 // pageToPermalinkFilename returns the URL-safe form of the filename
-func pageToPermalinkFilename(m *PageMetadata, _ string) (string, error) {
-	return URLEscape(m.Filepath)
+func pageToPermalinkFilename(m *PageMetadata, _ string, opts PathOptions) (string, error) {
+	return opts.URLize(m.Filepath), nil
+}
+
+func pageToPermalinkTitle(m *PageMetadata, _ string, opts PathOptions) (string, error) {
+	return opts.URLize(m.Title), nil
 }
 
-func pageToPermalinkTitle(m *PageMetadata, _ string) (string, error) {
-	return URLEscape(m.Title)
+func pageToPermalinkSection(m *PageMetadata, _ string, opts PathOptions) (string, error) {
+	return opts.URLize(m.Subdir), nil
 }
 
-func pageToPermalinkSection(m *PageMetadata, _ string) (string, error) {
-	return URLEscape(m.Subdir)
+// pageToPermalinkSections returns all sections of the page as path
+func pageToPermalinkSections(m *PageMetadata, _ string, opts PathOptions) (string, error) {
+	return urlizeSections(m.Sections, opts), nil
+}
+
+func urlizeSections(sections []string, opts PathOptions) string {
+	urlized := make([]string, 0, len(sections))
+	for _, section := range sections {
+		urlized = append(urlized, opts.URLize(section))
+	}
+
+	return path.Join(urlized...)
+}
+
+// toSliceFunc returns a function that slices the sections with the go slice syntax in brackets,
+// [last] is the index of the last element.
+func toSliceFunc(cut string) func(s []string) []string {
+	cut = strings.ToLower(strings.TrimSpace(cut))
+	if cut == "" {
+		return func(s []string) []string {
+			return s
+		}
+	}
+
+	if len(cut) < 3 || (cut[0] != '[' || cut[len(cut)-1] != ']') {
+		return func(s []string) []string {
+			return nil
+		}
+	}
+
+	toNFunc := func(s string, low bool) func(ss []string) int {
+		if s == "" {
+			if low {
+				return func(ss []string) int {
+					return 0
+				}
+			}
+
+			return func(ss []string) int {
+				return len(ss)
+			}
+		}
+
+		if s == "last" {
+			return func(ss []string) int {
+				return len(ss) - 1
+			}
+		}
+
+		n, _ := strconv.Atoi(s)
+		if n < 0 {
+			n = 0
+		}
+
+		return func(ss []string) int {
+			// prevent out of bound situations
+			if n >= len(ss) {
+				if low {
+					return -1
+				}
+
+				return len(ss)
+			}
+
+			return n
+		}
+	}
+
+	opsStr := cut[1 : len(cut)-1]
+	opts := strings.Split(opsStr, ":")
+
+	if !strings.Contains(opsStr, ":") {
+		toN := toNFunc(opts[0], true)
+
+		return func(s []string) []string {
+			if len(s) == 0 {
+				return nil
+			}
+
+			n := toN(s)
+			if n < 0 {
+				return []string{}
+			}
+
+			return []string{s[n]}
+		}
+	}
+
+	toN1, toN2 := toNFunc(opts[0], true), toNFunc(opts[1], false)
+
+	return func(s []string) []string {
+		if len(s) == 0 {
+			return nil
+		}
+
+		n1, n2 := toN1(s), toN2(s)
+		if n1 < 0 || n2 < 0 || n1 > n2 {
+			return []string{}
+		}
+
+		return s[n1:n2]
+	}
 }
 
 func URLEscape(uri string) (string, error) {
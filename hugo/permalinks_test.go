@@ -0,0 +1,80 @@
+package hugo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPathPatternExpand(t *testing.T) {
+	page := PageMetadata{
+		Title:    "Hello World",
+		Filepath: "post",
+		Subdir:   "blog",
+		Sections: []string{"blog", "2023", "deep"},
+		Date:     time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		pattern string
+		want    string
+		err     bool
+	}{
+		{"/:section/:title/", "/blog/hello-world/", false},
+		{"/:sections/:title/", "/blog/2023/deep/hello-world/", false},
+		{"/:sections[1:]/:slugorfilename/", "/2023/deep/post/", false},
+		{"/:sections[last]/:title/", "/deep/hello-world/", false},
+		{"/:sections[:last]/", "/blog/2023/", false},
+		{"/:sections[0]/:title/", "/blog/hello-world/", false},
+		{"/:sections[1:10]/", "/2023/deep/", false},
+		// out of range indexes expand to nothing
+		{"/:sections[5]/:title/", "//hello-world/", false},
+		{"/:sections[3:]/", "//", false},
+		{"/:2006-01-02/:title/", "/2023-01-02/hello-world/", false},
+		{"/:year/:month/:day/:filename/", "/2023/01/02/post/", false},
+		{"/:year/:monthname/:contentbasename/", "/2023/January/post/", false},
+		{"/:foo/", "", true},
+		{"/a//b/", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		m := page
+
+		got, err := PathPattern(tt.pattern).Expand(&m, PathOptions{})
+		if (err != nil) != tt.err {
+			t.Errorf("Expand(%q) error %v, want error %v", tt.pattern, err, tt.err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestPathPatternExpandSections(t *testing.T) {
+	tests := []struct {
+		sections []string
+		pattern  string
+		want     string
+	}{
+		{[]string{"blog"}, "/:sections[1:]/:title/", "//hello/"},
+		{[]string{"blog"}, "/:sections[last]/:title/", "/blog/hello/"},
+		{nil, "/:sections[last]/:title/", "//hello/"},
+		{[]string{"Über Uns", "Team"}, "/:sections/", "/%C3%BCber-uns/team/"},
+	}
+
+	for _, tt := range tests {
+		m := PageMetadata{Title: "Hello", Sections: tt.sections}
+
+		got, err := PathPattern(tt.pattern).Expand(&m, PathOptions{})
+		if err != nil {
+			t.Errorf("Expand(%q) with sections %v: %v", tt.pattern, tt.sections, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("Expand(%q) with sections %v = %q, want %q", tt.pattern, tt.sections, got, tt.want)
+		}
+	}
+}
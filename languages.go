@@ -0,0 +1,177 @@
+package hugoext
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+const defaultContentLanguage = "en"
+
+// languages is the multilingual setup of the site. Pages of the default language are written to
+// the destination root unless defaultContentLanguageInSubdir is set, all others below /<lang>/.
+type languages struct {
+	Default  string
+	InSubdir bool
+	List     []hugo.Language
+}
+
+// newLanguages returns the configured languages, the default language is always known.
+func newLanguages(list []hugo.Language, defaultLang string, inSubdir bool) languages {
+	if defaultLang == "" {
+		defaultLang = defaultContentLanguage
+	}
+
+	langs := languages{Default: strings.ToLower(defaultLang), InSubdir: inSubdir, List: list}
+	if !langs.known(langs.Default) {
+		langs.List = append(langs.List, hugo.Language{Lang: langs.Default})
+	}
+
+	return langs
+}
+
+func (langs languages) known(lang string) bool {
+	for _, l := range langs.List {
+		if l.Lang == lang {
+			return true
+		}
+	}
+
+	return false
+}
+
+// get returns the config of a language.
+func (langs languages) get(lang string) hugo.Language {
+	for _, l := range langs.List {
+		if l.Lang == lang {
+			return l
+		}
+	}
+
+	return hugo.Language{Lang: lang}
+}
+
+// split removes a language suffix like in post.de from a file name, it returns the name and the
+// language or the unchanged name and the fallback.
+func (langs languages) split(name, fallback string) (string, string) {
+	ext := path.Ext(name)
+	if ext == "" {
+		return name, fallback
+	}
+
+	if lang := strings.ToLower(ext[1:]); langs.known(lang) {
+		return strings.TrimSuffix(name, ext), lang
+	}
+
+	return name, fallback
+}
+
+// dir returns the output directory of a language, empty for the default language in the root.
+func (langs languages) dir(lang string) string {
+	if lang == langs.Default && !langs.InSubdir {
+		return ""
+	}
+
+	return lang
+}
+
+// destination prefixes the destination of a page with its language directory, pages with the url
+// front matter are placed as is.
+func (langs languages) destination(file *File) string {
+	dir := langs.dir(file.Lang)
+	if dir == "" || file.Metadata.URL != "" {
+		return file.Destination
+	}
+
+	dest := path.Join("/", dir, file.Destination)
+	if strings.HasSuffix(file.Destination, "/") {
+		dest += "/"
+	}
+
+	return dest
+}
+
+// dirs returns the output directories of all languages.
+func (langs languages) dirs() []string {
+	dirs := make([]string, 0, len(langs.List))
+	for _, l := range langs.List {
+		dirs = append(dirs, langs.dir(l.Lang))
+	}
+
+	return dirs
+}
+
+// contentDirs maps the content directory of each language to the language, the source directory
+// holds the pages of the default language and those with a language suffix.
+func (langs languages) contentDirs(source string) map[string]string {
+	dirs := map[string]string{filepath.Clean(source): ""}
+
+	for _, l := range langs.List {
+		if l.ContentDir != "" {
+			dirs[filepath.Clean(l.ContentDir)] = l.Lang
+		}
+	}
+
+	return dirs
+}
+
+// Translation links a page to the same page in another language.
+type Translation struct {
+	Lang         string
+	LanguageName string
+	Title        string
+	Link         string
+}
+
+// translationKey identifies the translations of a page, by the translationKey front matter or the
+// path of the page in its content directory without language.
+func translationKey(file *File) string {
+	if key, ok := file.Metadata.Params["translationkey"].(string); ok && key != "" {
+		return key
+	}
+
+	return filepath.Join(file.Parent, file.Name)
+}
+
+// linkTranslations sets the translations of each page in the tree, ordered like the languages.
+func linkTranslations(tree *FileTree, langs languages, ext string, uglyURLs bool) {
+	pages := make(map[string][]int)
+	for i := range tree.Files {
+		key := translationKey(&tree.Files[i])
+		pages[key] = append(pages[key], i)
+	}
+
+	order := make(map[string]int, len(langs.List))
+	for i, l := range langs.List {
+		order[l.Lang] = i
+	}
+
+	for _, indices := range pages {
+		if len(indices) < 2 {
+			continue
+		}
+
+		sort.SliceStable(indices, func(i, j int) bool {
+			return order[tree.Files[indices[i]].Lang] < order[tree.Files[indices[j]].Lang]
+		})
+
+		for _, i := range indices {
+			for _, j := range indices {
+				if i == j {
+					continue
+				}
+
+				other := &tree.Files[j]
+				tree.Files[i].Translations = append(tree.Files[i].Translations, Translation{
+					Lang:         other.Lang,
+					LanguageName: langs.get(other.Lang).LanguageName,
+					Title:        other.Metadata.Title,
+					Link:         pageLink(other.Destination, ext, uglyURLs, other.home()),
+				})
+			}
+		}
+	}
+}
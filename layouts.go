@@ -0,0 +1,90 @@
+package hugoext
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+const defaultLayoutDir = "layouts"
+
+// layouts looks up the templates for the output format in the hugo layout directory, e.g.
+// layouts/posts/li.gmi or layouts/_default/single.gmi.
+type layouts struct {
+	dir string
+	ext string
+}
+
+// lookup returns the template of the first of the directories that has one, falling back to
+// _default. It returns nil if there is no such template.
+func (l layouts) lookup(name string, dirs ...string) (*template.Template, error) {
+	for _, dir := range append(dirs, "_default") {
+		if dir == "" || dir == "." {
+			continue
+		}
+
+		file := filepath.Join(l.dir, dir, name+"."+l.ext)
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+
+		tmpl, err := template.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("parse template: %w", err)
+		}
+
+		return tmpl, nil
+	}
+
+	return nil, nil
+}
+
+// pageData is what single page templates are executed with.
+type pageData struct {
+	hugo.PageMetadata
+	// Content is the processed page content.
+	Content string
+	Section string
+	// Translations are the same page in the other languages.
+	Translations []Translation
+}
+
+// render wraps the processed content of a page in its single page template, the layout from front
+// matter or single, if one exists for the page type or section.
+func (l layouts) render(file *File) ([]byte, error) {
+	name := "single"
+	if file.Metadata.Layout != "" {
+		name = file.Metadata.Layout
+	}
+
+	section := firstSection(file.Parent)
+
+	tmpl, err := l.lookup(name, file.Metadata.Type, section)
+	if err != nil || tmpl == nil {
+		return file.NewBody, err
+	}
+
+	var buf bytes.Buffer
+
+	err = tmpl.Execute(&buf, pageData{
+		PageMetadata: file.Metadata,
+		Content:      string(file.NewBody),
+		Section:      section,
+		Translations: file.Translations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("execute template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// firstSection returns the top level content directory of a parent path.
+func firstSection(parent string) string {
+	return strings.Split(filepath.ToSlash(parent), "/")[0]
+}
@@ -0,0 +1,271 @@
+package hugoext
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// markdownLinkRegexp matches inline links like [text](target "title") and reference definitions
+// like [id]: target, the target is the second or third group.
+var markdownLinkRegexp = regexp.MustCompile(`(!?\[[^\]]*\]\()([^)\s]+)((?:\s+"[^"]*")?\))|(?m)(^\s{0,3}\[[^\]]+\]:\s*)(\S+)`)
+
+// pageIndex finds pages by their content path for ref, relref and internal links.
+type pageIndex struct {
+	langs    languages
+	ext      string
+	uglyURLs bool
+	baseURL  string
+	log      logger
+
+	// pages by content path without extension and language, e.g. posts/first
+	pages map[string][]*File
+	// pages by file name for refs that are neither relative nor absolute, e.g. first
+	names map[string][]*File
+	// links of the pages as hugo writes them, to the link in the output format
+	permalinks map[string]string
+}
+
+func newPageIndex(tree *FileTree, langs languages, ext string, uglyURLs bool, baseURL string,
+	log logger) *pageIndex {
+	idx := &pageIndex{
+		langs:      langs,
+		ext:        ext,
+		uglyURLs:   uglyURLs,
+		baseURL:    baseURL,
+		log:        log,
+		pages:      make(map[string][]*File),
+		names:      make(map[string][]*File),
+		permalinks: make(map[string]string),
+	}
+
+	for i := range tree.Files {
+		file := &tree.Files[i]
+
+		rel, err := filepath.Rel(file.Root, file.Source)
+		if err != nil {
+			continue
+		}
+
+		key := refKey(filepath.ToSlash(rel))
+		key, _ = langs.split(key, "")
+		idx.pages[key] = append(idx.pages[key], file)
+
+		// bundles and sections are also found by their directory
+		switch base := path.Base(key); base {
+		case "index", "_index":
+			dir := path.Dir(key)
+			idx.pages[dir] = append(idx.pages[dir], file)
+			idx.names[path.Base(dir)] = append(idx.names[path.Base(dir)], file)
+		default:
+			idx.names[base] = append(idx.names[base], file)
+		}
+
+		idx.permalinks[pageLink(file.Destination, "html", uglyURLs, file.home())] = idx.link(file)
+	}
+
+	return idx
+}
+
+// refKey returns the content path of a reference without leading slash and content extension.
+func refKey(ref string) string {
+	ext := path.Ext(ref)
+	if contentExtensions[ext] {
+		ref = strings.TrimSuffix(ref, ext)
+	}
+
+	return strings.TrimPrefix(path.Clean("/"+ref), "/")
+}
+
+func (idx *pageIndex) link(file *File) string {
+	return pageLink(file.Destination, idx.ext, idx.uglyURLs, file.home())
+}
+
+// lookup finds the page a reference from the given page points to, relative to the page first,
+// from the content root second and by file name last. Pages in the language of the referring page
+// are preferred, a reference that matches several pages is an error. A missing page is nil.
+func (idx *pageIndex) lookup(from *File, ref string) (*File, error) {
+	if ext := path.Ext(ref); contentExtensions[ext] {
+		ref = strings.TrimSuffix(ref, ext)
+	}
+
+	ref, lang := idx.langs.split(ref, from.Lang)
+
+	var candidates [][]*File
+
+	if !strings.HasPrefix(ref, "/") {
+		if rel, err := filepath.Rel(from.Root, filepath.Dir(from.Source)); err == nil {
+			candidates = append(candidates, idx.pages[refKey(path.Join(filepath.ToSlash(rel), ref))])
+		}
+	}
+
+	candidates = append(candidates, idx.pages[refKey(ref)])
+
+	if !strings.Contains(ref, "/") {
+		candidates = append(candidates, idx.names[refKey(ref)])
+	}
+
+	for _, files := range candidates {
+		if len(files) == 0 {
+			continue
+		}
+
+		var matches []*File
+		for _, file := range files {
+			if file.Lang == lang {
+				matches = append(matches, file)
+			}
+		}
+
+		if len(matches) == 0 {
+			matches = files
+		}
+
+		if len(matches) > 1 {
+			sources := make([]string, len(matches))
+			for i, file := range matches {
+				sources[i] = file.Source
+			}
+
+			return nil, fmt.Errorf("ambiguous reference %q: %s", ref, strings.Join(sources, ", "))
+		}
+
+		return matches[0], nil
+	}
+
+	return nil, nil
+}
+
+// resolve returns the link of the page a reference points to with its anchor, for ref prefixed with
+// the base url of the output if there is one. It returns false for a missing page.
+func (idx *pageIndex) resolve(from *File, ref string, absolute bool) (string, bool, error) {
+	target, anchor := ref, ""
+	if i := strings.Index(ref, "#"); i >= 0 {
+		target, anchor = ref[:i], ref[i:]
+	}
+
+	// a bare anchor refers to the page itself
+	file := from
+	if target != "" {
+		var err error
+		if file, err = idx.lookup(from, target); err != nil {
+			return "", false, err
+		} else if file == nil {
+			return "", false, nil
+		}
+	}
+
+	link := idx.link(file) + anchor
+	if absolute && idx.baseURL != "" {
+		link = strings.TrimRight(idx.baseURL, "/") + link
+	}
+
+	return link, true, nil
+}
+
+// rewriteLinks points markdown links to content files and to hugo permalinks of pages at the
+// output of the page, dangling links to content files are reported and kept. Fenced code blocks are
+// left as they are.
+func (idx *pageIndex) rewriteLinks(from *File, body []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	// the fence of the code block we're in and the start of the text not written yet
+	var fence []byte
+
+	start, pos := 0, 0
+
+	for _, line := range bytes.SplitAfter(body, []byte("\n")) {
+		trimmed := bytes.TrimLeft(line, " ")
+
+		switch {
+		case fence == nil && (bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~"))):
+			text, err := idx.rewriteText(from, body[start:pos])
+			if err != nil {
+				return nil, err
+			}
+
+			out.Write(text)
+			fence, start = trimmed[:3], pos
+		case fence != nil && bytes.HasPrefix(trimmed, fence):
+			out.Write(body[start : pos+len(line)])
+			fence, start = nil, pos+len(line)
+		}
+
+		pos += len(line)
+	}
+
+	// an unclosed code block runs to the end
+	if fence != nil {
+		out.Write(body[start:])
+		return out.Bytes(), nil
+	}
+
+	text, err := idx.rewriteText(from, body[start:])
+	if err != nil {
+		return nil, err
+	}
+
+	out.Write(text)
+
+	return out.Bytes(), nil
+}
+
+// rewriteText rewrites the links of markdown text without code blocks.
+func (idx *pageIndex) rewriteText(from *File, text []byte) ([]byte, error) {
+	var rewriteErr error
+
+	out := markdownLinkRegexp.ReplaceAllFunc(text, func(match []byte) []byte {
+		m := markdownLinkRegexp.FindSubmatch(match)
+
+		prefix, target, suffix := m[1], string(m[2]), m[3]
+		if len(m[4]) > 0 {
+			prefix, target, suffix = m[4], string(m[5]), nil
+		}
+
+		link, ok, err := idx.rewriteLink(from, target)
+		if err != nil && rewriteErr == nil {
+			rewriteErr = err
+		}
+
+		if !ok {
+			return match
+		}
+
+		return []byte(string(prefix) + link + string(suffix))
+	})
+
+	return out, rewriteErr
+}
+
+func (idx *pageIndex) rewriteLink(from *File, target string) (string, bool, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false, nil
+	}
+
+	if contentExtensions[path.Ext(u.Path)] {
+		link, ok, err := idx.resolve(from, target, false)
+		if err != nil {
+			return "", false, err
+		} else if !ok {
+			idx.log.Printf("warning: dangling link %s in %s\n", target, from.Source)
+		}
+
+		return link, ok, nil
+	}
+
+	link, ok := idx.permalinks[u.EscapedPath()]
+	if !ok || link == u.EscapedPath() {
+		return "", false, nil
+	}
+
+	if u.Fragment != "" {
+		link += "#" + u.Fragment
+	}
+
+	return link, true, nil
+}
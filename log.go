@@ -0,0 +1,19 @@
+package hugoext
+
+import (
+	"fmt"
+	"io"
+)
+
+// logger writes the progress of a build to Options.Log, nothing is written without one.
+type logger struct {
+	w io.Writer
+}
+
+func (l logger) Printf(format string, args ...interface{}) {
+	if l.w == nil {
+		return
+	}
+
+	fmt.Fprintf(l.w, format, args...)
+}
@@ -0,0 +1,171 @@
+package hugoext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+// Manifest lists the files hugoext wrote to the destination, it's what allows to remove outputs of
+// previous runs without touching files written by hugo into the same tree.
+type Manifest struct {
+	Destination string          `json:"destination"`
+	Files       []ManifestEntry `json:"files"`
+}
+
+type ManifestEntry struct {
+	// Path is relative to the destination directory.
+	Path string `json:"path"`
+	// Source is the content or static file the output was created from, the content directory for
+	// section listings.
+	Source string `json:"source,omitempty"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256,omitempty"`
+	// Pipe is the processor command the content was piped through.
+	Pipe     string             `json:"pipe,omitempty"`
+	Metadata *hugo.PageMetadata `json:"metadata,omitempty"`
+	// Stale marks outputs of previous runs that are no longer generated.
+	Stale bool `json:"stale,omitempty"`
+}
+
+// Add records a written file, a file is only listed once with the details it was first added with.
+func (manifest *Manifest) Add(entry ManifestEntry) {
+	entry.Path = filepath.ToSlash(entry.Path)
+
+	for _, e := range manifest.Files {
+		if e.Path == entry.Path {
+			return
+		}
+	}
+
+	manifest.Files = append(manifest.Files, entry)
+}
+
+// readManifest loads the manifest of a previous run, a missing manifest is empty.
+func readManifest(file string) (*Manifest, error) {
+	var manifest Manifest
+
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return &manifest, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest %s: %w", file, err)
+	}
+
+	return &manifest, nil
+}
+
+// write stores the manifest as JSON. Sizes and hashes are taken from the destination files at this
+// point as section listings are appended to after the pages are written.
+func (manifest *Manifest) write(file string) error {
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+
+	for i, entry := range manifest.Files {
+		size, sum, err := hashFile(filepath.Join(manifest.Destination, filepath.FromSlash(entry.Path)))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("hash %s: %w", entry.Path, err)
+		}
+
+		manifest.Files[i].Bytes = size
+		manifest.Files[i].SHA256 = sum
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+
+	return os.WriteFile(file, append(data, '\n'), 0644)
+}
+
+// Orphans returns the files of the previous manifest that are not part of this one.
+func (manifest *Manifest) Orphans(previous *Manifest) []ManifestEntry {
+	current := make(map[string]bool)
+	for _, entry := range manifest.Files {
+		current[entry.Path] = true
+	}
+
+	var orphans []ManifestEntry
+	for _, entry := range previous.Files {
+		if !current[entry.Path] {
+			orphans = append(orphans, entry)
+		}
+	}
+
+	return orphans
+}
+
+func hashFile(file string) (int64, string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// removeOrphan deletes a file of a previous run and all parent directories that are left empty. A
+// file that doesn't match the recorded sha256 anymore was rewritten by someone else, e.g. hugo, and
+// is kept, removeOrphan returns false then. Paths outside of dest are refused.
+func removeOrphan(dest string, orphan ManifestEntry, log logger) (bool, error) {
+	rel := filepath.Clean(filepath.FromSlash(orphan.Path))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, fmt.Errorf("orphan %s outside of %s", rel, dest)
+	}
+
+	_, sum, err := hashFile(filepath.Join(dest, rel))
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("hash: %w", err)
+	}
+
+	if orphan.SHA256 == "" || sum != orphan.SHA256 {
+		return false, nil
+	}
+
+	if err := os.Remove(filepath.Join(dest, rel)); err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("remove: %w", err)
+	}
+
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		fullpath := filepath.Join(dest, dir)
+
+		entries, err := os.ReadDir(fullpath)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+
+		if err := os.Remove(fullpath); err != nil {
+			return false, fmt.Errorf("remove dir: %w", err)
+		}
+
+		log.Printf("rmdir %s\n", fullpath)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,131 @@
+package hugoext
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveOrphan(t *testing.T) {
+	tests := []struct {
+		name string
+		// files are written below dest before removing path
+		files   map[string]string
+		path    string
+		sha256  string
+		removed bool
+		err     bool
+		// exist and gone are checked after removing
+		exist []string
+		gone  []string
+	}{
+		{
+			name:  "outside of dest",
+			files: map[string]string{"a.gmi": "a"},
+			path:  "../a.gmi",
+			err:   true,
+			exist: []string{"a.gmi"},
+		},
+		{
+			name:  "absolute path",
+			files: map[string]string{"a.gmi": "a"},
+			path:  "/a.gmi",
+			err:   true,
+			exist: []string{"a.gmi"},
+		},
+		{
+			name:   "hash mismatch",
+			files:  map[string]string{"robots.txt": "rewritten"},
+			path:   "robots.txt",
+			sha256: sha256Hex("written"),
+			exist:  []string{"robots.txt"},
+		},
+		{
+			name:  "no hash",
+			files: map[string]string{"a.gmi": "a"},
+			path:  "a.gmi",
+			exist: []string{"a.gmi"},
+		},
+		{
+			name:    "missing file",
+			path:    "gone.gmi",
+			sha256:  sha256Hex("gone"),
+			removed: true,
+		},
+		{
+			name:    "removed",
+			files:   map[string]string{"a.gmi": "a", "b.gmi": "b"},
+			path:    "a.gmi",
+			sha256:  sha256Hex("a"),
+			removed: true,
+			exist:   []string{"b.gmi"},
+			gone:    []string{"a.gmi"},
+		},
+		{
+			name:    "empty parents removed",
+			files:   map[string]string{"posts/2021/a/index.gmi": "a", "posts/b/index.gmi": "b"},
+			path:    "posts/2021/a/index.gmi",
+			sha256:  sha256Hex("a"),
+			removed: true,
+			exist:   []string{"posts/b/index.gmi"},
+			gone:    []string{"posts/2021"},
+		},
+		{
+			name:    "dest kept",
+			files:   map[string]string{"posts/a/index.gmi": "a"},
+			path:    "posts/a/index.gmi",
+			sha256:  sha256Hex("a"),
+			removed: true,
+			exist:   []string{"."},
+			gone:    []string{"posts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "public")
+			if err := os.Mkdir(dest, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			for name, content := range tt.files {
+				file := filepath.Join(dest, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			removed, err := removeOrphan(dest, ManifestEntry{Path: tt.path, SHA256: tt.sha256}, logger{})
+			if (err != nil) != tt.err {
+				t.Fatalf("removeOrphan error %v, want error %v", err, tt.err)
+			}
+
+			if removed != tt.removed {
+				t.Errorf("removeOrphan removed %v, want %v", removed, tt.removed)
+			}
+
+			for _, name := range tt.exist {
+				if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name))); err != nil {
+					t.Errorf("%s: %v", name, err)
+				}
+			}
+
+			for _, name := range tt.gone {
+				if _, err := os.Stat(filepath.Join(dest, filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Errorf("%s still exists", name)
+				}
+			}
+		})
+	}
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
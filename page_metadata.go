@@ -1,23 +1,181 @@
-package main
+package hugoext
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/n0x1m/hugoext/hugo"
 )
 
-func NewContentFromMeta(meta map[string]interface{}) *hugo.PageMetadata {
-	return &hugo.PageMetadata{
-		Title:      stringFromInterface(meta["title"]),
-		Slug:       stringFromInterface(meta["slug"]),
-		Summary:    stringFromInterface(meta["summary"]),
-		Categories: stringArrayFromInterface(meta["categories"]),
-		Tags:       stringArrayFromInterface(meta["tags"]),
-		Date:       dateFromInterface(meta["date"]),
-		Draft:      boolFromInterface(meta["draft"]),
+// dateLayouts are the date formats hugo accepts in front matter.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05", // iso8601 without timezone
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"2006-01-02 15:04:05.999999999 -0700 MST", // time.Time.String()
+	"2006-01-02",
+	"02 Jan 2006",
+	"2006-01-02T15:04:05-0700", // RFC3339 without timezone hh:mm colon
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05Z07:00", // RFC3339 without T
+	"2006-01-02 15:04:05Z0700",  // RFC3339 without T or timezone hh:mm colon
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.Kitchen,
+	time.Stamp,
+	time.StampMilli,
+	time.StampMicro,
+	time.StampNano,
+}
+
+// dateAliases are the front matter keys hugo reads for each date identifier.
+var dateAliases = map[string][]string{
+	"date":        {"date"},
+	"lastmod":     {"lastmod", "modified"},
+	"publishdate": {"publishdate", "pubdate", "published"},
+	"expirydate":  {"expirydate", "unpublishdate"},
+}
+
+// frontmatterConfig is the order in which the page dates are resolved, configured in the hugo
+// [frontmatter] section. Besides front matter keys, identifiers can be :filename, :fileModTime,
+// :git and :default.
+type frontmatterConfig struct {
+	Date        []string
+	Lastmod     []string
+	PublishDate []string
+	ExpiryDate  []string
+}
+
+var defaultFrontmatterConfig = frontmatterConfig{
+	Date:        []string{"date", "publishDate", "lastmod"},
+	Lastmod:     []string{":git", "lastmod", "date", "publishDate"},
+	PublishDate: []string{"publishDate", "date"},
+	ExpiryDate:  []string{"expiryDate"},
+}
+
+func newFrontmatterConfig(cfg map[string]interface{}) frontmatterConfig {
+	fields := make(map[string]interface{}, len(cfg))
+	for k, v := range cfg {
+		fields[strings.ToLower(k)] = v
+	}
+
+	ids := func(key string, defaults []string) []string {
+		v, ok := fields[key]
+		if !ok {
+			return defaults
+		}
+
+		// a list of identifiers or a single one
+		var out []string
+		for _, id := range append(stringArrayFromInterface(v), stringFromInterface(v)) {
+			switch {
+			case id == "":
+			case strings.ToLower(id) == ":default":
+				out = append(out, defaults...)
+			default:
+				out = append(out, id)
+			}
+		}
+
+		return out
+	}
+
+	return frontmatterConfig{
+		Date:        ids("date", defaultFrontmatterConfig.Date),
+		Lastmod:     ids("lastmod", defaultFrontmatterConfig.Lastmod),
+		PublishDate: ids("publishdate", defaultFrontmatterConfig.PublishDate),
+		ExpiryDate:  ids("expirydate", defaultFrontmatterConfig.ExpiryDate),
 	}
 }
 
+// metadataConfig holds the site settings that affect how front matter is read.
+type metadataConfig struct {
+	// Location is used for dates without time zone, from the site's timeZone setting.
+	Location    *time.Location
+	Frontmatter frontmatterConfig
+	// GitLastmod are the last commit dates by real path if enableGitInfo is set.
+	GitLastmod map[string]time.Time
+	// SummaryLength is the number of words of automatic summaries.
+	SummaryLength int
+	// HasCJKLanguage enables detection of CJK content for word counts.
+	HasCJKLanguage bool
+}
+
+// pageSource is what page dates can be derived from besides the front matter.
+type pageSource struct {
+	// Filename is the base name without extension, the directory name for bundles.
+	Filename   string
+	ModTime    time.Time
+	GitLastmod time.Time
+}
+
+func newContentFromMeta(meta map[string]interface{}, src pageSource, cfg metadataConfig) (*hugo.PageMetadata, error) {
+	// front matter keys are case insensitive in hugo
+	params := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		params[strings.ToLower(k)] = v
+	}
+
+	c := &hugo.PageMetadata{
+		Title:       stringFromInterface(params["title"]),
+		Slug:        stringFromInterface(params["slug"]),
+		Summary:     stringFromInterface(params["summary"]),
+		Categories:  stringArrayFromInterface(params["categories"]),
+		Tags:        stringArrayFromInterface(params["tags"]),
+		Draft:       boolFromInterface(params["draft"]),
+		Weight:      intFromInterface(params["weight"]),
+		Description: stringFromInterface(params["description"]),
+		Aliases:     stringArrayFromInterface(params["aliases"]),
+		URL:         stringFromInterface(params["url"]),
+		Type:        stringFromInterface(params["type"]),
+		Layout:      stringFromInterface(params["layout"]),
+		Keywords:    stringArrayFromInterface(params["keywords"]),
+		Headless:    boolFromInterface(params["headless"]),
+		Params:      params,
+
+		IsCJKLanguage: boolFromInterface(params["iscjklanguage"]),
+	}
+
+	dates := []struct {
+		field *time.Time
+		ids   []string
+	}{
+		{&c.Date, cfg.Frontmatter.Date},
+		{&c.Lastmod, cfg.Frontmatter.Lastmod},
+		{&c.PublishDate, cfg.Frontmatter.PublishDate},
+		{&c.ExpiryDate, cfg.Frontmatter.ExpiryDate},
+	}
+
+	for _, d := range dates {
+		t, err := resolveDate(d.ids, params, src, c, cfg.Location)
+		if err != nil {
+			return nil, err
+		}
+
+		*d.field = t
+	}
+
+	// lastmod and publishDate fall back to the page date like in hugo
+	if c.Lastmod.IsZero() {
+		c.Lastmod = c.Date
+	}
+
+	if c.PublishDate.IsZero() {
+		c.PublishDate = c.Date
+	}
+
+	return c, nil
+}
+
 func stringFromInterface(input interface{}) string {
 	str, _ := input.(string)
 	return str
@@ -28,24 +186,120 @@ func boolFromInterface(input interface{}) bool {
 	return v
 }
 
-func dateFromInterface(input interface{}) time.Time {
-	str, ok := input.(string)
-	if !ok {
-		return time.Now()
+func intFromInterface(input interface{}) int {
+	switch v := input.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
 	}
 
-	t, err := time.Parse(time.RFC3339, str)
-	if err != nil {
-		// try just date, or give up
-		t, err := time.Parse("2006-01-02", str)
-		if err != nil {
-			return time.Now()
+	return 0
+}
+
+// dateFromInterface parses TOML datetimes and all date strings hugo accepts, dates without time
+// zone are in loc. An empty input is the zero time.
+func dateFromInterface(input interface{}, loc *time.Location) (time.Time, error) {
+	switch v := input.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		// toml local dates and date-times carry no zone and are decoded in time.Local
+		if v.Location() == time.Local && loc != nil {
+			return time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(),
+				loc), nil
+		}
+
+		return v, nil
+	case string:
+		if v == "" {
+			return time.Time{}, nil
+		}
+
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, v, loc); err == nil {
+				return t, nil
+			}
 		}
 
-		return t
+		return time.Time{}, fmt.Errorf("unable to parse date %q", v)
+	}
+
+	return time.Time{}, fmt.Errorf("unable to parse date %v of type %T", input, input)
+}
+
+// resolveDate returns the first date found by the identifiers in order, or the zero time. A date
+// taken from the filename also sets the slug if the front matter has none.
+func resolveDate(ids []string, params map[string]interface{}, src pageSource, c *hugo.PageMetadata,
+	loc *time.Location) (time.Time, error) {
+	for _, id := range ids {
+		var t time.Time
+
+		switch id = strings.ToLower(id); id {
+		case ":filename":
+			var slug string
+			t, slug = dateFromFilename(src.Filename, loc)
+
+			if !t.IsZero() && c.Slug == "" {
+				c.Slug = slug
+			}
+		case ":filemodtime":
+			t = src.ModTime
+		case ":git":
+			t = src.GitLastmod
+		default:
+			keys, ok := dateAliases[id]
+			if !ok {
+				keys = []string{id}
+			}
+
+			var err error
+			if t, err = dateFromParams(params, loc, keys...); err != nil {
+				return t, err
+			}
+		}
+
+		if !t.IsZero() {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, nil
+}
+
+// dateFromFilename extracts the date of filenames like 2021-03-04-my-post and returns the remainder
+// as slug.
+func dateFromFilename(name string, loc *time.Location) (time.Time, string) {
+	if len(name) < 10 {
+		return time.Time{}, ""
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", name[:10], loc)
+	if err != nil {
+		return time.Time{}, ""
+	}
+
+	return t, strings.Trim(name[10:], " -_")
+}
+
+// dateFromParams returns the date of the first key set, or the zero time.
+func dateFromParams(params map[string]interface{}, loc *time.Location, keys ...string) (time.Time, error) {
+	for _, key := range keys {
+		if v, ok := params[key]; ok {
+			t, err := dateFromInterface(v, loc)
+			if err != nil {
+				return t, fmt.Errorf("%s: %w", key, err)
+			}
+
+			return t, nil
+		}
 	}
 
-	return t
+	return time.Time{}, nil
 }
 
 func stringArrayFromInterface(input interface{}) []string {
@@ -1,17 +1,22 @@
-package main
+package hugoext
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func pipe(cmd string, input io.Reader) ([]byte, error) {
-	extpipe := exec.Command(cmd)
+func pipe(ctx context.Context, cmd string, input io.Reader, env []string) ([]byte, error) {
+	extpipe := exec.CommandContext(ctx, cmd)
 	extpipe.Stdin = input
+	extpipe.Env = append(os.Environ(), env...)
 
 	var pipeout bytes.Buffer
 	extpipe.Stdout = &pipeout
@@ -27,24 +32,52 @@ func pipe(cmd string, input io.Reader) ([]byte, error) {
 	return pipeout.Bytes(), nil
 }
 
-func targetPath(dest, newext string, uglyURLs bool) (dir string, filename string) {
+// pipeEnv exposes the page metadata to the processor as environment variables.
+func pipeEnv(page *Page) []string {
+	return []string{
+		"HUGOEXT_SOURCE=" + page.Source,
+		"HUGOEXT_DESTINATION=" + page.Destination,
+		"HUGOEXT_TITLE=" + page.Metadata.Title,
+		"HUGOEXT_LANG=" + page.Lang,
+		"HUGOEXT_DATE=" + page.Metadata.Date.Format(time.RFC3339),
+		"HUGOEXT_LASTMOD=" + page.Metadata.Lastmod.Format(time.RFC3339),
+		"HUGOEXT_WORDCOUNT=" + strconv.Itoa(page.Metadata.WordCount),
+		"HUGOEXT_READINGTIME=" + strconv.Itoa(page.Metadata.ReadingTime),
+	}
+}
+
+// targetPath returns the directory and file a destination is written to, home is the root page of
+// the site or of a language which is always the index file of its directory.
+func targetPath(dest, newext string, uglyURLs, home bool) (dir string, filename string) {
 	filename = "index." + newext
 	dir = dest
 
-	if uglyURLs && dest != "index" {
+	home = home || strings.Trim(dest, "/") == ""
+
+	if uglyURLs && !home {
+		// a trailing slash from a permalink or url only makes a directory with pretty urls
+		dest = strings.TrimSuffix(dest, "/")
+
 		// make the last element in destination the file
 		filename = filepath.Base(dest) + "." + newext
 		// set the parent directory of that file to be the dir to create
 		dir = filepath.Dir(dest)
 	}
 
-	if dest == "index" {
-		dir = "."
+	if home {
+		dir = filepath.Dir(dest)
 	}
 
 	return
 }
 
+// pageLink returns the escaped site relative link a destination is served under.
+func pageLink(dest, newext string, uglyURLs, home bool) string {
+	dir, filename := targetPath(dest, newext, uglyURLs, home)
+
+	return escapeLink(sitemapLink(filepath.Join(dir, filename)))
+}
+
 func mkdir(dir string) (bool, error) {
 	// skip if this exists
 	if _, err := os.Stat(dir); err == nil {
@@ -57,3 +90,23 @@ func mkdir(dir string) (bool, error) {
 
 	return true, nil
 }
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open source: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create destination: %w", err)
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("copy: %w", err)
+	}
+
+	return out.Close()
+}
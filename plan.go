@@ -0,0 +1,159 @@
+package hugoext
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Plan is the dry run report of what a build would write.
+type Plan struct {
+	Entries []PlanEntry
+}
+
+type PlanEntry struct {
+	Source      string
+	Destination string
+	Status      string
+	Listings    []string
+}
+
+// newPlan lists the outputs of the build. Pages show the size of their output or the error of the
+// pipe if it ran, pipeErrs is nil otherwise.
+func newPlan(tree *FileTree, static []StaticFile, sections map[string]*Section, langs languages,
+	destination, ext, seconOnRoot string, uglyURLs bool, pipeErrs map[string]error) *Plan {
+	var plan Plan
+
+	// the root listing of a language is named after its directory
+	root := func(dir string) string {
+		return filepath.Join(dir, "root")
+	}
+
+	listings := func(file File) []string {
+		dir := langs.dir(file.Lang)
+
+		name := filepath.Join(dir, file.Parent)
+		if _, ok := sections[name]; !ok {
+			return nil
+		}
+
+		names := []string{name}
+		if seconOnRoot != "" && file.Parent == seconOnRoot {
+			names = append(names, root(dir))
+		}
+
+		return names
+	}
+
+	for _, file := range tree.Files {
+		outdir, outfile := targetPath(file.Destination, ext, uglyURLs, file.home())
+
+		status := "write"
+		if err, ok := pipeErrs[file.Source]; ok {
+			status = "pipe failed: " + err.Error()
+		} else if pipeErrs != nil {
+			status = fmt.Sprintf("write (%dbytes)", len(file.NewBody))
+		}
+
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Source:      file.Source,
+			Destination: filepath.Join(destination, outdir, outfile),
+			Status:      status,
+			Listings:    listings(file),
+		})
+	}
+
+	for _, file := range tree.Files {
+		for _, alias := range file.Metadata.Aliases {
+			outdir, outfile := targetPath(aliasDestination(&file, alias, langs.dir(file.Lang)), ext, uglyURLs, false)
+
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Source:      file.Source,
+				Destination: filepath.Join(destination, outdir, outfile),
+				Status:      "alias to " + pageLink(file.Destination, ext, uglyURLs, file.home()),
+			})
+		}
+	}
+
+	for _, file := range tree.Resources {
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Source:      file.Source,
+			Destination: filepath.Join(destination, file.Destination),
+			Status:      "copy",
+		})
+	}
+
+	for _, file := range tree.Skipped {
+		entry := PlanEntry{Source: file.Source, Destination: "-", Status: "skip " + file.Skip}
+
+		if !file.Resource {
+			outdir, outfile := targetPath(file.Destination, ext, uglyURLs, file.home())
+			entry.Destination = filepath.Join(destination, outdir, outfile)
+		}
+
+		plan.Entries = append(plan.Entries, entry)
+	}
+
+	for _, file := range static {
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Source:      file.Source,
+			Destination: filepath.Join(destination, file.Destination),
+			Status:      "copy",
+		})
+	}
+
+	for name, section := range sections {
+		status := fmt.Sprintf("listing (%d entries)", len(section.List))
+		if section.Append {
+			status = "append " + status
+		}
+
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Source:      "-",
+			Destination: section.File,
+			Status:      status,
+			Listings:    []string{name},
+		})
+	}
+
+	for _, dir := range langs.dirs() {
+		section, ok := sections[filepath.Join(dir, seconOnRoot)]
+		if !ok || seconOnRoot == "" {
+			continue
+		}
+
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Source:      "-",
+			Destination: filepath.Join(destination, dir, "index."+ext),
+			Status:      fmt.Sprintf("append listing (%d entries)", len(section.List)),
+			Listings:    []string{root(dir)},
+		})
+	}
+
+	sort.SliceStable(plan.Entries, func(i, j int) bool {
+		return plan.Entries[i].Destination < plan.Entries[j].Destination
+	})
+
+	return &plan
+}
+
+// Print writes the plan as a table.
+func (plan *Plan) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "SOURCE\tDESTINATION\tSTATUS\tLISTING")
+
+	for _, entry := range plan.Entries {
+		listing := "-"
+		if len(entry.Listings) > 0 {
+			listing = strings.Join(entry.Listings, ", ")
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", entry.Source, entry.Destination, entry.Status, listing)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,55 @@
+package hugoext
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+// Page is a published page as processors get it.
+type Page struct {
+	Source      string
+	Destination string
+	Lang        string
+	Metadata    hugo.PageMetadata
+	// Content is the markdown with front matter after rendering shortcodes and rewriting links.
+	Content []byte
+}
+
+// Processor converts the markdown of a page to the output format, the result is wrapped in the
+// single page layout if there is one.
+type Processor interface {
+	Process(ctx context.Context, page *Page) ([]byte, error)
+}
+
+// ProcessorFunc is a function used as Processor.
+type ProcessorFunc func(ctx context.Context, page *Page) ([]byte, error)
+
+func (f ProcessorFunc) Process(ctx context.Context, page *Page) ([]byte, error) {
+	return f(ctx, page)
+}
+
+// Pipe is the Processor that pipes the content through an external program, the page metadata is
+// passed as HUGOEXT_ environment variables. Without a command the content is passed through.
+type Pipe struct {
+	Command string
+}
+
+func (p Pipe) Process(ctx context.Context, page *Page) ([]byte, error) {
+	if p.Command == "" {
+		return page.Content, nil
+	}
+
+	return pipe(ctx, p.Command, bytes.NewReader(page.Content), pipeEnv(page))
+}
+
+// processorName describes the processor for logs and the manifest.
+func processorName(p Processor) string {
+	if pipe, ok := p.(Pipe); ok {
+		return pipe.Command
+	}
+
+	return fmt.Sprintf("%T", p)
+}
@@ -1,40 +1,103 @@
-package main
+package hugoext
 
 import (
 	"bytes"
 	"fmt"
 	"os"
 	"sort"
+	"text/template"
 	"time"
 )
 
+// sectionSorts are the orders a section list can be sorted in.
+var sectionSorts = map[string]func(a, b SectionEntry) bool{
+	"date": func(a, b SectionEntry) bool {
+		return a.Date.After(b.Date)
+	},
+	"lastmod": func(a, b SectionEntry) bool {
+		return a.Lastmod.After(b.Lastmod)
+	},
+	// like hugo's default order, pages without weight come last
+	"weight": func(a, b SectionEntry) bool {
+		if a.Weight != b.Weight {
+			if a.Weight == 0 || b.Weight == 0 {
+				return b.Weight == 0
+			}
+
+			return a.Weight < b.Weight
+		}
+
+		if !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+
+		return a.Title < b.Title
+	},
+	"title": func(a, b SectionEntry) bool {
+		return a.Title < b.Title
+	},
+}
+
+// defaultEntryTemplate is used for section list entries without a li template in the layouts.
+var defaultEntryTemplate = template.Must(template.New("li").Parse(
+	"\n=> {{ .Link }} {{ .Date.Format \"2006-01-02\" }}: {{ .Title }}\n{{ .Summary }}\n"))
+
 type Section struct {
 	List []SectionEntry
 	File string
+	// Source is the content directory of the section.
+	Source string
+	// Append adds the listing to the page written to File instead of replacing the file.
+	Append   bool
+	Sort     string
+	Template *template.Template
 }
 
 type SectionEntry struct {
-	Link    string
-	Title   string
-	Date    time.Time
-	Summary string
+	Link        string
+	Title       string
+	Date        time.Time
+	Lastmod     time.Time
+	Weight      int
+	Summary     string
+	WordCount   int
+	ReadingTime int
 }
 
-func (section *Section) Write(file string) error {
-	// sort section list
-	sort.Slice(section.List, func(i, j int) bool {
-		return section.List[i].Date.After(section.List[j].Date)
+// Lastmod returns the most recent modification of all entries in the section.
+func (section *Section) Lastmod() time.Time {
+	var lastmod time.Time
+	for _, entry := range section.List {
+		if entry.Lastmod.After(lastmod) {
+			lastmod = entry.Lastmod
+		}
+	}
+
+	return lastmod
+}
+
+func (section *Section) write(file string) error {
+	// sort section list, newest first by default
+	less, ok := sectionSorts[section.Sort]
+	if !ok {
+		less = sectionSorts["date"]
+	}
+
+	sort.SliceStable(section.List, func(i, j int) bool {
+		return less(section.List[i], section.List[j])
 	})
 
-	var buf bytes.Buffer
+	tmpl := section.Template
+	if tmpl == nil {
+		tmpl = defaultEntryTemplate
+	}
 
-	for _, file := range section.List {
-		// TODO: this could be a template
-		entry := "\n"
-		entry += fmt.Sprintf("=> %s %v: %s\n", file.Link, file.Date.Format("2006-01-02"), file.Title)
-		entry += fmt.Sprintf("%s\n", file.Summary)
+	var buf bytes.Buffer
 
-		buf.Write([]byte(entry))
+	for _, entry := range section.List {
+		if err := tmpl.Execute(&buf, entry); err != nil {
+			return fmt.Errorf("execute template: %w", err)
+		}
 	}
 
 	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
@@ -0,0 +1,315 @@
+package hugoext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+// shortcodeRegexp matches hugo shortcode tags like {{< name args >}}, {{% /name %}} and the escaped
+// form {{</* name */>}}.
+var shortcodeRegexp = regexp.MustCompile(`(?s)\{\{([<%])(\s*/\*)?(.*?)(\*/\s*)?([>%])\}\}`)
+
+// shortcodeArgRegexp matches a named or positional argument, quoted with double quotes or
+// backticks, or a bare word.
+var shortcodeArgRegexp = regexp.MustCompile("(?s)(?:([\\w\\-]+)=)?(\"(?:[^\"\\\\]|\\\\.)*\"|`[^`]*`|[^\\s\"`]+)")
+
+// shortcodes renders hugo shortcodes in page content with the templates in
+// layouts/shortcodes/<name>.<ext>, shortcodes without a template are removed. The ref and relref
+// shortcodes are built in unless there is a template for them.
+type shortcodes struct {
+	layouts   layouts
+	refs      *pageIndex
+	templates map[string]*template.Template
+	log       logger
+}
+
+func newShortcodes(l layouts, refs *pageIndex, log logger) *shortcodes {
+	return &shortcodes{layouts: l, refs: refs, templates: make(map[string]*template.Template), log: log}
+}
+
+// shortcodeData is what shortcode templates are executed with, like hugo's shortcode context.
+type shortcodeData struct {
+	Name string
+	// Inner is the rendered content between the opening and closing tag.
+	Inner string
+	// Params are the named arguments as map or the positional arguments as list.
+	Params        interface{}
+	IsNamedParams bool
+	Page          *hugo.PageMetadata
+}
+
+// Get returns a positional argument by index or a named argument by name, or an empty string.
+func (data shortcodeData) Get(key interface{}) interface{} {
+	switch params := data.Params.(type) {
+	case map[string]string:
+		if name, ok := key.(string); ok {
+			return params[strings.ToLower(name)]
+		}
+	case []string:
+		if i, ok := key.(int); ok && i >= 0 && i < len(params) {
+			return params[i]
+		}
+	}
+
+	return ""
+}
+
+// shortcodeTag is a piece of content, either text or a shortcode tag.
+type shortcodeTag struct {
+	text string
+
+	name    string
+	params  interface{}
+	named   bool
+	closing bool
+	self    bool
+}
+
+func (tag shortcodeTag) isTag() bool {
+	return tag.name != ""
+}
+
+// template returns the shortcode template by name, nil if there is none.
+func (sc *shortcodes) template(name string) (*template.Template, error) {
+	if tmpl, ok := sc.templates[name]; ok {
+		return tmpl, nil
+	}
+
+	var tmpl *template.Template
+
+	file := filepath.Join(sc.layouts.dir, "shortcodes", name+"."+sc.layouts.ext)
+	if _, err := os.Stat(file); err == nil {
+		tmpl, err = template.ParseFiles(file)
+		if err != nil {
+			return nil, fmt.Errorf("parse shortcode template: %w", err)
+		}
+	}
+
+	sc.templates[name] = tmpl
+
+	return tmpl, nil
+}
+
+// render replaces the shortcodes in the page body with their rendered templates.
+func (sc *shortcodes) render(file *File) ([]byte, error) {
+	tags := lexShortcodes(string(file.Body))
+
+	// pair opening and closing tags, an opening tag without a closing one stands alone
+	pairs := make(map[int]int)
+
+	var open []int
+
+	for i, tag := range tags {
+		switch {
+		case !tag.isTag() || tag.self:
+		case !tag.closing:
+			open = append(open, i)
+		default:
+			j := len(open) - 1
+			for j >= 0 && tags[open[j]].name != tag.name {
+				j--
+			}
+
+			if j < 0 {
+				sc.log.Printf("warning: closing shortcode %s without opening tag in %s\n", tag.name, file.Source)
+				continue
+			}
+
+			pairs[open[j]] = i
+			open = open[:j]
+		}
+	}
+
+	out, err := sc.renderTags(file, tags, pairs, 0, len(tags))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(out), nil
+}
+
+// renderTags renders the tags from index i up to but excluding end.
+func (sc *shortcodes) renderTags(file *File, tags []shortcodeTag, pairs map[int]int, i, end int) (string, error) {
+	var buf strings.Builder
+
+	for ; i < end; i++ {
+		tag := tags[i]
+
+		if !tag.isTag() {
+			buf.WriteString(tag.text)
+			continue
+		}
+
+		// dangling closing tags were reported while pairing
+		if tag.closing {
+			continue
+		}
+
+		var inner string
+
+		if j, ok := pairs[i]; ok {
+			var err error
+
+			inner, err = sc.renderTags(file, tags, pairs, i+1, j)
+			if err != nil {
+				return "", err
+			}
+
+			i = j
+		}
+
+		tmpl, err := sc.template(tag.name)
+		if err != nil {
+			return "", fmt.Errorf("shortcode %s: %w", tag.name, err)
+		}
+
+		if tmpl == nil && (tag.name == "ref" || tag.name == "relref") {
+			link, err := sc.ref(file, tag)
+			if err != nil {
+				return "", fmt.Errorf("shortcode %s: %w", tag.name, err)
+			}
+
+			buf.WriteString(link)
+
+			continue
+		}
+
+		if tmpl == nil {
+			sc.log.Printf("warning: no shortcode layout for %s in %s, removed\n", tag.name, file.Source)
+			buf.WriteString(inner)
+
+			continue
+		}
+
+		err = tmpl.Execute(&buf, shortcodeData{
+			Name:          tag.name,
+			Inner:         inner,
+			Params:        tag.params,
+			IsNamedParams: tag.named,
+			Page:          &file.Metadata,
+		})
+		if err != nil {
+			return "", fmt.Errorf("shortcode %s: execute template: %w", tag.name, err)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// ref returns the link of the page the ref or relref shortcode points to, the path is the first or
+// the named path argument. Dangling references are reported and written as is.
+func (sc *shortcodes) ref(file *File, tag shortcodeTag) (string, error) {
+	data := shortcodeData{Params: tag.params}
+
+	target, _ := data.Get(0).(string)
+	if tag.named {
+		target, _ = data.Get("path").(string)
+	}
+
+	link, ok, err := sc.refs.resolve(file, target, tag.name == "ref")
+	if err != nil {
+		return "", err
+	} else if !ok {
+		sc.log.Printf("warning: dangling %s %s in %s\n", tag.name, target, file.Source)
+		return target, nil
+	}
+
+	return link, nil
+}
+
+// lexShortcodes splits content into text and shortcode tags, escaped shortcodes become text
+// without the comment markers.
+func lexShortcodes(content string) []shortcodeTag {
+	var tags []shortcodeTag
+
+	last := 0
+
+	for _, m := range shortcodeRegexp.FindAllStringSubmatchIndex(content, -1) {
+		delim, closeDelim := content[m[2]:m[3]], content[m[10]:m[11]]
+
+		// {{< has to be closed with >}} and {{% with %}}
+		if (delim == "<") != (closeDelim == ">") {
+			continue
+		}
+
+		tags = append(tags, shortcodeTag{text: content[last:m[0]]})
+		last = m[1]
+
+		body := strings.TrimSpace(content[m[6]:m[7]])
+
+		if m[4] >= 0 && m[8] >= 0 {
+			tags = append(tags, shortcodeTag{text: "{{" + delim + " " + body + " " + closeDelim + "}}"})
+			continue
+		}
+
+		tag, ok := parseShortcodeTag(body)
+		if !ok {
+			tag = shortcodeTag{text: content[m[0]:m[1]]}
+		}
+
+		tags = append(tags, tag)
+	}
+
+	return append(tags, shortcodeTag{text: content[last:]})
+}
+
+// parseShortcodeTag parses the name and arguments between the delimiters of a tag, it returns false
+// if there is no name.
+func parseShortcodeTag(body string) (shortcodeTag, bool) {
+	var tag shortcodeTag
+
+	if strings.HasPrefix(body, "/") {
+		tag.closing = true
+		body = strings.TrimSpace(body[1:])
+	}
+
+	if strings.HasSuffix(body, "/") {
+		tag.self = true
+		body = strings.TrimSpace(body[:len(body)-1])
+	}
+
+	fields := strings.Fields(body)
+	if len(fields) == 0 {
+		return tag, false
+	}
+
+	tag.name = fields[0]
+
+	named := make(map[string]string)
+
+	var positional []string
+
+	for _, m := range shortcodeArgRegexp.FindAllStringSubmatch(strings.TrimPrefix(body, tag.name), -1) {
+		value := unquoteShortcodeArg(m[2])
+
+		if m[1] != "" {
+			named[strings.ToLower(m[1])] = value
+		} else {
+			positional = append(positional, value)
+		}
+	}
+
+	tag.params = positional
+	if len(named) > 0 {
+		tag.params, tag.named = named, true
+	}
+
+	return tag, true
+}
+
+func unquoteShortcodeArg(arg string) string {
+	switch {
+	case len(arg) >= 2 && arg[0] == '`' && arg[len(arg)-1] == '`':
+		return arg[1 : len(arg)-1]
+	case len(arg) >= 2 && arg[0] == '"' && arg[len(arg)-1] == '"':
+		return strings.ReplaceAll(arg[1:len(arg)-1], `\"`, `"`)
+	}
+
+	return arg
+}
@@ -0,0 +1,141 @@
+package hugoext
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestLexShortcodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []shortcodeTag
+	}{
+		{
+			name:    "text only",
+			content: "no shortcodes",
+			want:    []shortcodeTag{{text: "no shortcodes"}},
+		},
+		{
+			name:    "named args",
+			content: `a {{< figure src="x.png" Alt=y >}} b`,
+			want: []shortcodeTag{
+				{text: "a "},
+				{name: "figure", params: map[string]string{"src": "x.png", "alt": "y"}, named: true},
+				{text: " b"},
+			},
+		},
+		{
+			name:    "quoted args",
+			content: "{{< say \"a \\\"b\\\"\" `c d` e >}}",
+			want: []shortcodeTag{
+				{text: ""},
+				{name: "say", params: []string{`a "b"`, "c d", "e"}},
+				{text: ""},
+			},
+		},
+		{
+			name:    "escaped",
+			content: "see {{</* figure src=\"x.png\" */>}} and {{%/* note */%}}",
+			want: []shortcodeTag{
+				{text: "see "},
+				{text: `{{< figure src="x.png" >}}`},
+				{text: " and "},
+				{text: "{{% note %}}"},
+				{text: ""},
+			},
+		},
+		{
+			name:    "paired",
+			content: "{{% note %}}inner{{% /note %}}",
+			want: []shortcodeTag{
+				{text: ""},
+				{name: "note", params: []string(nil)},
+				{text: "inner"},
+				{name: "note", params: []string(nil), closing: true},
+				{text: ""},
+			},
+		},
+		{
+			name:    "self closing",
+			content: "{{< br />}}",
+			want: []shortcodeTag{
+				{text: ""},
+				{name: "br", params: []string(nil), self: true},
+				{text: ""},
+			},
+		},
+		{
+			name:    "mismatched delimiters",
+			content: "{{< note %}} and {{% note >}}",
+			want:    []shortcodeTag{{text: "{{< note %}} and {{% note >}}"}},
+		},
+		{
+			name:    "no name",
+			content: "{{<  >}}",
+			want:    []shortcodeTag{{text: ""}, {text: "{{<  >}}"}, {text: ""}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lexShortcodes(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lexShortcodes(%q)\n got %#v\nwant %#v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortcodesRender(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"positional", `{{< inner x >}}`, "(x:)"},
+		{"self closing", `{{< inner y />}}`, "(y:)"},
+		{"paired", `{{< inner x >}}text{{< /inner >}}`, "(x:text)"},
+		{"nested", `{{< outer >}}a {{< inner x >}}b{{< /inner >}}{{< /outer >}}`, "[a (x:b)]"},
+		{"nested same name", `{{< outer >}}1{{< outer >}}2{{< /outer >}}{{< /outer >}}`, "[1[2]]"},
+		{"escaped", `{{</* inner x */>}}`, `{{< inner x >}}`},
+		{"no layout", `{{< missing >}}kept{{< /missing >}}`, "kept"},
+		{"dangling closing", `a{{< /outer >}}b`, "ab"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := newShortcodes(layouts{dir: t.TempDir(), ext: "gmi"}, nil, logger{})
+			sc.templates["outer"] = template.Must(template.New("outer").Parse("[{{ .Inner }}]"))
+			sc.templates["inner"] = template.Must(template.New("inner").Parse("({{ .Get 0 }}:{{ .Inner }})"))
+
+			got, err := sc.render(&File{Body: []byte(tt.body)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("render(%q) = %q, want %q", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripShortcodes(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{`a {{< figure src="x.png" >}} b`, "a  b"},
+		{`{{% note %}}inner{{% /note %}}`, "inner"},
+		{`see {{</* figure */>}}`, "see {{< figure >}}"},
+		{`{{< note %}} stays`, "{{< note %}} stays"},
+	}
+
+	for _, tt := range tests {
+		if got := string(stripShortcodes([]byte(tt.content))); got != tt.want {
+			t.Errorf("stripShortcodes(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
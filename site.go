@@ -0,0 +1,24 @@
+package hugoext
+
+import (
+	"time"
+
+	"github.com/n0x1m/hugoext/hugo"
+)
+
+// Site is the site a build was made for, the settings of the hugo config with the options applied.
+type Site struct {
+	Title    string
+	BaseURL  string
+	UglyURLs bool
+	TimeZone *time.Location
+
+	// Languages are ordered by weight and include the default language.
+	Languages       []hugo.Language
+	DefaultLanguage string
+	// DefaultLanguageInSubdir writes the default language below /<lang>/ like all others.
+	DefaultLanguageInSubdir bool
+
+	// Permalinks are the patterns by section, other sections use /:year/:month/:title/.
+	Permalinks map[string]string
+}
@@ -0,0 +1,137 @@
+package hugoext
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const sitemapXMLNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type Sitemap struct {
+	BaseURL string
+	Entries []SitemapEntry
+}
+
+type SitemapEntry struct {
+	Loc     string
+	Lastmod time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+}
+
+// Add registers a written file by its path relative to the destination directory.
+func (sitemap *Sitemap) Add(rel string, lastmod time.Time) {
+	sitemap.Entries = append(sitemap.Entries, SitemapEntry{
+		Loc:     strings.TrimRight(sitemap.BaseURL, "/") + escapeLink(sitemapLink(rel)),
+		Lastmod: lastmod,
+	})
+}
+
+// sitemapLink turns a file path relative to the destination into the link it is served under,
+// index files are addressed by their directory.
+func sitemapLink(rel string) string {
+	rel = filepath.ToSlash(rel)
+	dir, file := path.Split(rel)
+
+	if strings.HasPrefix(file, "index.") {
+		link := path.Join("/", dir)
+		if link != "/" {
+			link += "/"
+		}
+
+		return link
+	}
+
+	return path.Join("/", rel)
+}
+
+// escapeLink escapes a link to a written file like hugo's URLizeFilename, unicode letters are percent
+// encoded and valid escapes are kept.
+func escapeLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	return u.String()
+}
+
+// sort orders the entries by location and merges duplicates, e.g. a section listing appended to a
+// written page, keeping the most recent lastmod.
+func (sitemap *Sitemap) sort() {
+	sort.SliceStable(sitemap.Entries, func(i, j int) bool {
+		return sitemap.Entries[i].Loc < sitemap.Entries[j].Loc
+	})
+
+	var entries []SitemapEntry
+	for _, entry := range sitemap.Entries {
+		last := len(entries) - 1
+		if last >= 0 && entries[last].Loc == entry.Loc {
+			if entry.Lastmod.After(entries[last].Lastmod) {
+				entries[last].Lastmod = entry.Lastmod
+			}
+
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sitemap.Entries = entries
+}
+
+// writeXML writes the entries as an XML sitemap.
+func (sitemap *Sitemap) writeXML(file string) error {
+	sitemap.sort()
+
+	set := sitemapURLSet{XMLNS: sitemapXMLNS}
+	for _, entry := range sitemap.Entries {
+		u := sitemapURL{Loc: entry.Loc}
+		if !entry.Lastmod.IsZero() {
+			u.Lastmod = entry.Lastmod.Format("2006-01-02T15:04:05-07:00")
+		}
+
+		set.URLs = append(set.URLs, u)
+	}
+
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("xml marshal: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.Write(out)
+	buf.WriteString("\n")
+
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// writeText writes the entries as a plain list of URLs, one per line.
+func (sitemap *Sitemap) writeText(file string) error {
+	sitemap.sort()
+
+	var buf bytes.Buffer
+	for _, entry := range sitemap.Entries {
+		buf.WriteString(entry.Loc + "\n")
+	}
+
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
@@ -0,0 +1,91 @@
+package hugoext
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+type StaticFile struct {
+	Source      string
+	Destination string
+}
+
+// collectStatic walks the static directories in order and returns their files by path relative to
+// the directory. Files of later directories override files of earlier ones, missing directories are
+// skipped.
+func collectStatic(dirs ...string) ([]StaticFile, error) {
+	sources := make(map[string]string)
+
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return fmt.Errorf("rel path: %w", err)
+			}
+
+			sources[rel] = p
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("static walk %s: %w", dir, err)
+		}
+	}
+
+	files := make([]StaticFile, 0, len(sources))
+	for rel, src := range sources {
+		files = append(files, StaticFile{Source: src, Destination: rel})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Destination < files[j].Destination
+	})
+
+	return files, nil
+}
+
+// sync copies the static file into dest unless the destination has the same size and modification
+// time. It returns the written path and whether the file was copied.
+func (file StaticFile) sync(dest string, log logger) (string, bool, error) {
+	fullpath := filepath.Join(dest, file.Destination)
+
+	src, err := os.Stat(file.Source)
+	if err != nil {
+		return fullpath, false, fmt.Errorf("stat source: %w", err)
+	}
+
+	if dst, err := os.Stat(fullpath); err == nil && dst.Size() == src.Size() && dst.ModTime().Equal(src.ModTime()) {
+		return fullpath, false, nil
+	}
+
+	newdir := filepath.Dir(fullpath)
+	if made, err := mkdir(newdir); err != nil {
+		return fullpath, false, err
+	} else if made {
+		log.Printf("mkdir %s\n", newdir)
+	}
+
+	if err := copyFile(file.Source, fullpath); err != nil {
+		return fullpath, false, err
+	}
+
+	// keep the source modification time to detect unchanged files on the next run
+	if err := os.Chtimes(fullpath, src.ModTime(), src.ModTime()); err != nil {
+		return fullpath, true, fmt.Errorf("chtimes: %w", err)
+	}
+
+	return fullpath, true, nil
+}
@@ -0,0 +1,113 @@
+package hugoext
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	summaryDivider       = "<!--more-->"
+	defaultSummaryLength = 70
+)
+
+var (
+	markdownStrip = []struct {
+		re   *regexp.Regexp
+		repl string
+	}{
+		{regexp.MustCompile(`(?s)<!--.*?-->`), ""},
+		{regexp.MustCompile(`(?m)^\s*(` + "```" + `|~~~).*$`), ""},
+		{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+		{regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`), "$1"},
+		{regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`), "$1"},
+		{regexp.MustCompile(`(?m)^\s*\[[^\]]+\]:\s.*$`), ""},
+		{regexp.MustCompile(`<[^>]+>`), ""},
+		{regexp.MustCompile(`(?m)^\s{0,3}#{1,6}\s*`), ""},
+		{regexp.MustCompile(`(?m)^\s{0,3}>\s?`), ""},
+		{regexp.MustCompile(`(?m)^\s*([-*+]|\d+\.)\s+`), ""},
+		{regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`), ""},
+		{regexp.MustCompile("[*_~`]+"), ""},
+	}
+)
+
+// summarize derives a summary like hugo does, the content before the summary divider or else the
+// first words of the content up to the end of the sentence, as plain text.
+func summarize(content []byte, length int) string {
+	if i := bytes.Index(content, []byte(summaryDivider)); i >= 0 {
+		return strings.Join(strings.Fields(stripMarkdown(string(content[:i]))), " ")
+	}
+
+	return truncateWordsToWholeSentence(stripMarkdown(string(content)), length)
+}
+
+// removeSummaryDivider returns the document without the summary divider.
+func removeSummaryDivider(body []byte) []byte {
+	return bytes.Replace(body, []byte(summaryDivider), nil, 1)
+}
+
+// stripShortcodes removes the shortcode tags from the content, the inner content of paired tags and
+// escaped shortcodes are kept. Summaries and word counts are derived before the shortcodes are
+// rendered.
+func stripShortcodes(content []byte) []byte {
+	var buf bytes.Buffer
+
+	for _, tag := range lexShortcodes(string(content)) {
+		if !tag.isTag() {
+			buf.WriteString(tag.text)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// stripMarkdown removes markdown syntax and html from the text.
+func stripMarkdown(text string) string {
+	for _, strip := range markdownStrip {
+		text = strip.re.ReplaceAllString(text, strip.repl)
+	}
+
+	return text
+}
+
+// cjkRegexp matches chinese, japanese and korean characters.
+var cjkRegexp = regexp.MustCompile(`\p{Han}|\p{Hangul}|\p{Hiragana}|\p{Katakana}`)
+
+// countWords returns the word count of the content and the reading time in minutes like hugo, words
+// with CJK characters count each character if the page is in a CJK language.
+func countWords(content []byte, isCJKLanguage bool) (int, int) {
+	var count int
+
+	for _, word := range strings.Fields(stripMarkdown(string(content))) {
+		if isCJKLanguage && cjkRegexp.MatchString(word) {
+			count += utf8.RuneCountInString(word)
+		} else {
+			count++
+		}
+	}
+
+	if isCJKLanguage {
+		return count, (count + 500) / 501
+	}
+
+	return count, (count + 212) / 213
+}
+
+// truncateWordsToWholeSentence takes at least max words and continues until the sentence ends.
+func truncateWordsToWholeSentence(text string, max int) string {
+	words := strings.Fields(text)
+
+	for i, word := range words {
+		if i+1 < max {
+			continue
+		}
+
+		if r, _ := utf8.DecodeLastRuneInString(word); unicode.Is(unicode.Sentence_Terminal, r) {
+			return strings.Join(words[:i+1], " ")
+		}
+	}
+
+	return strings.Join(words, " ")
+}